@@ -0,0 +1,181 @@
+package glarg
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// How deep response files can include other response files.
+	RESPONSE_FILE_MAX_DEPTH = 10
+)
+
+// SplitArgs splits s into arguments the way a shell would,
+// without any of the expansions. Words are separated by
+// whitespace, single quotes keep everything literally, double
+// quotes allow \" and \\ escapes, a backslash escapes the next
+// character and a # at the start of a word comments out the
+// rest of the line.
+func SplitArgs(s string) ([]string, error) {
+	args := []string{}
+	var word strings.Builder
+	inWord := false
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '\\':
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("trailing backslash")
+			}
+			// A backslash before a newline continues the line.
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case c == '\'':
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated single quote")
+			}
+			inWord = true
+		case c == '"':
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated double quote")
+			}
+			inWord = true
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// ExpandResponseFiles replaces every argument of the form @path
+// with the arguments read from path using SplitArgs. Response
+// files can include other response files up to maxDepth deep.
+// Nothing after a "--" argument is expanded. Flag values which
+// start with @, like a SecretFlag's, must use the -flag=@value
+// form to not be taken for a response file. Subcommands keeps the
+// values of the SecretFlags of the commands given in both forms.
+func ExpandResponseFiles(args []string, maxDepth int) ([]string, error) {
+	e := &responseFileExpander{maxDepth: maxDepth, args: []string{}}
+	if err := e.expand(args, 0); err != nil {
+		return nil, err
+	}
+	return e.args, nil
+}
+
+// responseFileExpander expands response files while following the
+// arguments down the tree from cmd, like Subcommands does, to know
+// the flags of the commands given. cmd is nil when there is no
+// tree.
+type responseFileExpander struct {
+	cmd      Subcommand
+	maxDepth int
+	args     []string
+}
+
+func (self *responseFileExpander) expand(args []string, depth int) error {
+	for i, v := range args {
+		if v == "--" {
+			self.args = append(self.args, args[i:]...)
+			return nil
+		}
+		if !strings.HasPrefix(v, "@") || len(v) == 1 || self.isSecretValue() {
+			self.add(v)
+			continue
+		}
+
+		if depth >= self.maxDepth {
+			return fmt.Errorf("response file %s: too many nested response files", v[1:])
+		}
+		content, err := os.ReadFile(v[1:])
+		if err != nil {
+			return fmt.Errorf("response file %s: %s", v[1:], err)
+		}
+		fileArgs, err := SplitArgs(string(content))
+		if err != nil {
+			return fmt.Errorf("response file %s: %s", v[1:], err)
+		}
+		if err := self.expand(fileArgs, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingFlag returns the flag of the current command which the
+// next argument is the value of.
+func (self *responseFileExpander) pendingFlag() *flag.Flag {
+	n := len(self.args)
+	if self.cmd == nil || n == 0 {
+		return nil
+	}
+	f, value := flagAt(self.cmd.FlagSet(), append(self.args[:n:n], ""), n-1)
+	if value != n {
+		return nil
+	}
+	return f
+}
+
+// isSecretValue is true when the next argument is the value of a
+// SecretFlag given as "-flag value".
+func (self *responseFileExpander) isSecretValue() bool {
+	f := self.pendingFlag()
+	if f == nil {
+		return false
+	}
+	_, ok := f.Value.(*SecretFlag)
+	return ok
+}
+
+// add adds v, moving on to the command it names, or to the Default
+// one for a flag the Subcommands does not have.
+func (self *responseFileExpander) add(v string) {
+	sc, ok := self.cmd.(*Subcommands)
+	if ok && self.pendingFlag() == nil {
+		name := v
+		if strings.HasPrefix(v, "-") {
+			flagName, _, _ := strings.Cut(strings.TrimLeft(v, "-"), "=")
+			name = ""
+			if sc.FlagSet() == nil || sc.FlagSet().Lookup(flagName) == nil {
+				name = sc.Default
+			}
+		}
+		for _, child := range sc.Children {
+			if name != "" && child.FlagSet() != nil && child.FlagSet().Name() == name {
+				self.cmd = child
+				break
+			}
+		}
+	}
+	self.args = append(self.args, v)
+}
@@ -0,0 +1,178 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"a b\tc\nd", []string{"a", "b", "c", "d"}},
+		{"  leading and trailing  ", []string{"leading", "and", "trailing"}},
+		{"'single quoted' \"double quoted\"", []string{"single quoted", "double quoted"}},
+		{`'it''s' "say \"hi\"" "a\b"`, []string{"its", `say "hi"`, `a\b`}},
+		{`escaped\ space`, []string{"escaped space"}},
+		{"line \\\ncontinued", []string{"line", "continued"}},
+		{"# comment\na # another\nb#not", []string{"a", "b#not"}},
+		{`"" ''`, []string{"", ""}},
+		{`-flag="a b"`, []string{"-flag=a b"}},
+	}
+	for _, v := range cases {
+		received, err := SplitArgs(v.input)
+		if err != nil {
+			t.Errorf("Error. Expected %q to split. Received: %s", v.input, err)
+		} else if strings.Join(received, "|") != strings.Join(v.expected, "|") || len(received) != len(v.expected) {
+			t.Errorf("Error. Input: %q. Expected: %q. Received: %q.", v.input, v.expected, received)
+		}
+	}
+
+	for _, v := range []string{"'open", `"open`, `trailing\`} {
+		if _, err := SplitArgs(v); err == nil {
+			t.Errorf("Error. Expected %q to fail.", v)
+		}
+	}
+}
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.txt")
+	outer := filepath.Join(dir, "outer.txt")
+	loop := filepath.Join(dir, "loop.txt")
+	os.WriteFile(inner, []byte("# ids\nc\nd\n"), 0644)
+	os.WriteFile(outer, []byte("b @"+inner+" 'e f'\n"), 0644)
+	os.WriteFile(loop, []byte("@"+loop), 0644)
+
+	received, err := ExpandResponseFiles([]string{"a", "@" + outer, "g", "--", "@" + outer}, RESPONSE_FILE_MAX_DEPTH)
+	if err != nil {
+		t.Fatalf("Error. Expected expansion to work. Received: %s", err)
+	}
+	expected := []string{"a", "b", "c", "d", "e f", "g", "--", "@" + outer}
+	if strings.Join(received, "|") != strings.Join(expected, "|") {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, received)
+	}
+
+	if _, err := ExpandResponseFiles([]string{"@" + loop}, RESPONSE_FILE_MAX_DEPTH); err == nil {
+		t.Errorf("Error. Expected the recursion limit to fail.")
+	}
+	if _, err := ExpandResponseFiles([]string{"@" + filepath.Join(dir, "missing")}, RESPONSE_FILE_MAX_DEPTH); err == nil {
+		t.Errorf("Error. Expected a missing file to fail.")
+	}
+	if received, _ := ExpandResponseFiles([]string{"@"}, RESPONSE_FILE_MAX_DEPTH); len(received) != 1 {
+		t.Errorf("Error. Expected a lone @ to be kept. Received: %q.", received)
+	}
+}
+
+type responseFileTestCommand struct {
+	SubcommandNoOp
+	ids   []string
+	token Secret
+}
+
+func (self *responseFileTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewSliceFlag(&StringSliceFlagTarget{&self.ids}, ""), "ids", "ids")
+	self.flagSet.Var(NewSecretFlag(&self.token), "token", "token")
+	return self
+}
+
+func TestSubcommandsResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "args.txt")
+	os.WriteFile(file, []byte("lookup -ids a,b,c\n"), 0644)
+
+	cmd := &responseFileTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "lookup"}}
+	root := &Subcommands{
+		Name:          "root",
+		ResponseFiles: true,
+		Children:      []Subcommand{&Subcommands{Name: "nested", Children: []Subcommand{cmd}}},
+	}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "nested", "@" + file})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if strings.Join(cmd.ids, ",") != "a,b,c" {
+		t.Errorf("Error. Expected: a,b,c. Received: %v.", cmd.ids)
+	}
+
+	// The @file of a SecretFlag is the secret, not a response file.
+	token := filepath.Join(dir, "token")
+	os.WriteFile(token, []byte("s3cr3t-value\n"), 0600)
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "@" + file, "-token", "@" + token})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if cmd.token.Reveal() != "s3cr3t-value" {
+		t.Errorf("Error. Expected: s3cr3t-value. Received: %q.", cmd.token.Reveal())
+	}
+
+	rc = Invoke(context.Background(), root, []string{"tool", "@" + filepath.Join(dir, "missing")})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
+
+type responseFileNameTestCommand struct {
+	SubcommandNoOp
+	token string
+	ids   []string
+}
+
+func (self *responseFileNameTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ContinueOnError)
+	self.flagSet.StringVar(&self.token, "token", "", "token")
+	self.flagSet.Var(NewSliceFlag(&StringSliceFlagTarget{&self.ids}, ""), "ids", "ids")
+	return self
+}
+
+func TestSubcommandsResponseFilesPath(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "args.txt")
+	os.WriteFile(file, []byte("lookup -ids a,b\n"), 0644)
+
+	// Only the SecretFlags of the command given keep their @file.
+	cmd := &responseFileNameTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "plain"}}
+	nested := &Subcommands{
+		Name:          "nested",
+		ResponseFiles: true,
+		Children:      []Subcommand{&responseFileTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "lookup"}}},
+	}
+	root := &Subcommands{
+		Name:          "root",
+		ResponseFiles: true,
+		Children:      []Subcommand{nested, cmd},
+	}
+	value := filepath.Join(dir, "value.txt")
+	os.WriteFile(value, []byte("from-file\n"), 0644)
+	rc := Invoke(context.Background(), root, []string{"tool", "plain", "-token", "@" + value, "-ids", "x"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if cmd.token != "from-file" || strings.Join(cmd.ids, ",") != "x" {
+		t.Errorf("Error. Expected: from-file x. Received: %s %v.", cmd.token, cmd.ids)
+	}
+
+	// The arguments are expanded once, by the outermost
+	// Subcommands, so what follows "--" stays as it is.
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "--", "@" + file})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(logs.String(), "Unknown subcommand provided: @"+file) {
+		t.Errorf("Error. Expected the argument to be left alone. Received: %s.", logs.String())
+	}
+}
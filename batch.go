@@ -0,0 +1,255 @@
+package glarg
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	// The longest line of a batch file.
+	BATCH_LINE_MAX_SIZE = 16 * 1024 * 1024
+)
+
+// BatchCommand is a Subcommand which runs a file of command lines
+// through Root, one after the other, in one process. Lines are
+// split with SplitArgs, so quotes, comments and continuation
+// lines work like in the shell. Each word then has ${NAME} and
+// $NAME replaced by the variables given with -var, or by the
+// environment. A value is never split into several words, and $$
+// is a literal $. By default the batch stops at the first line
+// that fails, -continue runs the rest of the lines. The failed
+// lines and a summary are logged at the end.
+//
+// To run a file as "tool -f commands.txt", make the BatchCommand
+// the Default of the root Subcommands.
+type BatchCommand struct {
+	flagSet   *flag.FlagSet
+	args      []string
+	Name      string
+	Root      Subcommand
+	input     *PathFlag
+	file      string
+	keepGoing bool
+	echo      bool
+	vars      batchVars
+	results   []BatchResult
+}
+
+func (self *BatchCommand) Description() string {
+	return "Run the commands in a file."
+}
+
+func (self *BatchCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *BatchCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.file = STDIO_PATH
+	self.input = NewPathFlag(&self.file, PathExpand)
+	self.flagSet.Var(self.input, "f", "The file of commands, - for stdin.")
+	self.flagSet.BoolVar(&self.keepGoing, "continue", false, "Keep going after a command fails.")
+	self.flagSet.BoolVar(&self.echo, "echo", false, "Print each command before it is run.")
+	self.vars = batchVars{}
+	self.flagSet.Var(self.vars, "var", "Set a variable as NAME=VALUE. Can be repeated.")
+	return self
+}
+
+func (self *BatchCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *BatchCommand) SetArgs(args []string) {
+	self.args = args
+}
+
+// Results returns the outcome of every line of the last batch
+// which was run, skipped lines excluded.
+func (self *BatchCommand) Results() []BatchResult {
+	return self.results
+}
+
+// BatchResult is the outcome of one command line of a batch.
+type BatchResult struct {
+	Line     int
+	Args     []string
+	ExitCode int
+	Err      error
+}
+
+func (self BatchResult) String() string {
+	if self.Err != nil {
+		return fmt.Sprintf("line %d: %s", self.Line, self.Err)
+	}
+	return fmt.Sprintf("line %d: exit %d: %s", self.Line, self.ExitCode, strings.Join(self.Args, " "))
+}
+
+func (self *BatchCommand) Execute(ctx context.Context) int {
+	env := Env(ctx)
+	if ctx.Value(inSessionKey{}) != nil {
+		env.logf(slog.LevelError, "Batches can not be run from a shell or another batch.")
+		return 1
+	}
+	ctx = context.WithValue(ctx, inSessionKey{}, true)
+
+	f, err := self.input.Open()
+	if err != nil {
+		env.logf(slog.LevelError, "Unable to open the commands. %s", err)
+		return 1
+	}
+	defer f.Close()
+
+	// Every line sets the tree up again, this command included,
+	// so the flags are copied before the first one.
+	keepGoing, echo, vars := self.keepGoing, self.echo, self.vars
+	session := NewSession(self.Root, self.Root.FlagSet().Name())
+	results := []BatchResult{}
+	skipped := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, BATCH_LINE_MAX_SIZE)
+	lineNumber := 0
+	for {
+		start, line, ok := readBatchLine(scanner, &lineNumber)
+		if !ok {
+			break
+		}
+
+		words, err := SplitArgs(line)
+		if err == nil {
+			words, err = vars.expand(env, words)
+		}
+		if err == nil && len(words) == 0 {
+			continue
+		}
+
+		failed := len(results) > 0 && results[len(results)-1].ExitCode != 0
+		if (failed && !keepGoing) || ctx.Err() != nil {
+			skipped++
+			continue
+		}
+		if err != nil {
+			results = append(results, BatchResult{Line: start, ExitCode: 1, Err: err})
+			continue
+		}
+
+		if echo {
+			fmt.Fprintf(env.Stdout, "+ %s\n", strings.Join(words, " "))
+		}
+		results = append(results, BatchResult{
+			Line:     start,
+			Args:     words,
+			ExitCode: session.Run(ctx, words, sessionOptions(ctx)...),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		env.logf(slog.LevelError, "Unable to read the commands. %s", err)
+		return 1
+	}
+
+	self.results = results
+	return self.summary(env, results, skipped)
+}
+
+// readBatchLine reads one command line, joining the lines ending
+// in a backslash with the next. It returns the number of the
+// first line.
+func readBatchLine(scanner *bufio.Scanner, lineNumber *int) (int, string, bool) {
+	start := *lineNumber + 1
+	line := ""
+	for scanner.Scan() {
+		*lineNumber++
+		text := scanner.Text()
+		trimmed := strings.TrimRight(text, "\\")
+		if (len(text)-len(trimmed))%2 == 1 {
+			line += text + "\n"
+			continue
+		}
+		return start, line + text, true
+	}
+	return start, line, line != ""
+}
+
+// summary logs the exit code of every line, the failed ones as
+// errors, and the totals. It returns the exit code of the first
+// failure.
+func (self *BatchCommand) summary(env *Environment, results []BatchResult, skipped int) int {
+	rc := 0
+	failed := 0
+	for _, v := range results {
+		if v.ExitCode == 0 {
+			env.logf(slog.LevelInfo, "%s", v)
+			continue
+		}
+		if rc == 0 {
+			rc = v.ExitCode
+		}
+		failed++
+		env.logf(slog.LevelError, "%s", v)
+	}
+	env.logf(slog.LevelInfo, "Ran %d commands, %d failed, %d skipped.", len(results), failed, skipped)
+	return rc
+}
+
+// batchVars is the value of the repeatable -var flag.
+type batchVars map[string]string
+
+func (self batchVars) String() string {
+	names := make([]string, 0, len(self))
+	for k := range self {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, v := range names {
+		pairs[i] = v + "=" + self[v]
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (self batchVars) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("variables must be set as NAME=VALUE")
+	}
+	self[name] = value
+	return nil
+}
+
+// Reset forgets the variables. See Resetter.
+func (self batchVars) Reset() {
+	for k := range self {
+		delete(self, k)
+	}
+}
+
+func (self batchVars) expand(env *Environment, words []string) ([]string, error) {
+	var missing []string
+	mapping := func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		if v, ok := self[name]; ok {
+			return v
+		}
+		if v, ok := env.LookupEnv(name); ok {
+			return v
+		}
+		missing = append(missing, name)
+		return ""
+	}
+
+	expanded := make([]string, len(words))
+	for i, v := range words {
+		expanded[i] = os.Expand(v, mapping)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("undefined variable %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
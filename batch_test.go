@@ -0,0 +1,152 @@
+package glarg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type batchTestFailCommand struct {
+	SubcommandNoOp
+}
+
+func (self *batchTestFailCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	return self
+}
+
+func (self *batchTestFailCommand) Execute(ctx context.Context) int {
+	return 3
+}
+
+func TestBatchCommand(t *testing.T) {
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	script := filepath.Join(t.TempDir(), "commands.txt")
+	os.WriteFile(script, []byte(strings.Join([]string{
+		"#!/usr/bin/env tool batch -f",
+		"get -ids 'a,b' -name ${NAME}",
+		"",
+		"get -name $$x \\",
+		"  -ids c",
+		"fail",
+		"get -name ${MISSING}",
+		"get",
+	}, "\n")), 0600)
+
+	cases := []struct {
+		args     []string
+		rc       int
+		expected string
+		summary  string
+	}{
+		{
+			[]string{"-var", "NAME=x y"},
+			3,
+			"a,b|00000000-0000-0000-0000-000000000000|x y c|00000000-0000-0000-0000-000000000000|$x",
+			"Ran 3 commands, 1 failed, 2 skipped.",
+		},
+		{
+			[]string{"-var", "NAME=z", "-continue"},
+			3,
+			"a,b|00000000-0000-0000-0000-000000000000|z c|00000000-0000-0000-0000-000000000000|$x |00000000-0000-0000-0000-000000000000|default",
+			"Ran 5 commands, 2 failed, 0 skipped.",
+		},
+	}
+	for _, v := range cases {
+		out.Reset()
+		cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+		root := &Subcommands{Name: "root", Children: []Subcommand{
+			cmd,
+			&batchTestFailCommand{SubcommandNoOp{Name: "fail"}},
+		}}
+		batch := &BatchCommand{Name: "batch", Root: root}
+		root.Children = append(root.Children, batch)
+
+		args := append([]string{"tool", "batch", "-f", script}, v.args...)
+		if rc := Invoke(context.Background(), root, args); rc != v.rc {
+			t.Errorf("Error. Args: %v. Expected: %d. Received: %d.", v.args, v.rc, rc)
+		}
+		if strings.Join(cmd.results, " ") != v.expected {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %v.", v.args, v.expected, cmd.results)
+		}
+		if !strings.Contains(out.String(), v.summary) || !strings.Contains(out.String(), "line 6: exit 3: fail") {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %s.", v.args, v.summary, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "line 7: undefined variable MISSING") {
+		t.Errorf("Error. Expected the undefined variable. Received: %s.", out.String())
+	}
+}
+
+func TestBatchRecoverPanics(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "commands.txt")
+	os.WriteFile(script, []byte("boom\nget -name after\n"), 0600)
+
+	cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	boom := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd, boom}}
+	root.Children = append(root.Children, &BatchCommand{Name: "batch", Root: root})
+
+	dir := t.TempDir()
+	rc := Invoke(context.Background(), root, []string{"tool", "batch", "-f", script, "-continue"}, RecoverPanics(dir))
+	if rc != EXIT_PANIC {
+		t.Errorf("Error. Expected: %d. Received: %d.", EXIT_PANIC, rc)
+	}
+	expected := "|00000000-0000-0000-0000-000000000000|after"
+	if strings.Join(cmd.results, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %v.", expected, cmd.results)
+	}
+	if reports, _ := filepath.Glob(filepath.Join(dir, "crash-*.txt")); len(reports) != 1 {
+		t.Errorf("Error. Expected 1 crash report. Received: %v.", reports)
+	}
+}
+
+func TestBatchDefault(t *testing.T) {
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	// A line longer than the default buffer of a bufio.Scanner.
+	ids := make([]string, 20000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%05d", i)
+	}
+	script := filepath.Join(t.TempDir(), "commands.txt")
+	os.WriteFile(script, []byte("get -ids "+strings.Join(ids, ",")+"\nfail\nget -name x\n"), 0600)
+
+	cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	batch := &BatchCommand{Name: "batch"}
+	root := &Subcommands{Name: "root", Default: "batch", Children: []Subcommand{
+		cmd,
+		&batchTestFailCommand{SubcommandNoOp{Name: "fail"}},
+		batch,
+	}}
+	batch.Root = root
+
+	if rc := Invoke(context.Background(), root, []string{"tool", "-f", script, "-continue"}); rc != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", rc)
+	}
+	if len(cmd.results) != 2 || len(cmd.results[0]) < 64*1024 {
+		t.Errorf("Error. Expected the long line to run. Received: %d results.", len(cmd.results))
+	}
+
+	codes := []string{}
+	for _, v := range batch.Results() {
+		codes = append(codes, fmt.Sprintf("%d:%d", v.Line, v.ExitCode))
+	}
+	if strings.Join(codes, " ") != "1:0 2:3 3:0" {
+		t.Errorf("Error. Expected: %s. Received: %v.", "1:0 2:3 3:0", codes)
+	}
+	for _, v := range []string{"line 2: exit 3: fail", "line 3: exit 0: get -name x", "Ran 3 commands, 1 failed, 0 skipped."} {
+		if !strings.Contains(out.String(), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, out.String())
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package glarg
+
+import (
+	"context"
+	"time"
+)
+
+type clockKey struct{}
+
+// WithClock makes Now return the time from now for the commands
+// run by Invoke, so that tests can fake the time.
+func WithClock(now func() time.Time) InvokeOption {
+	return func(self *invocation) {
+		self.clock = now
+	}
+}
+
+// Now returns the current time, or the time of the clock given
+// to Invoke with WithClock. Commands should use it instead of
+// time.Now.
+func Now(ctx context.Context) time.Time {
+	if now, ok := ctx.Value(clockKey{}).(func() time.Time); ok {
+		return now()
+	}
+	return time.Now()
+}
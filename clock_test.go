@@ -0,0 +1,39 @@
+package glarg
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type clockTestCommand struct {
+	SubcommandNoOp
+	now time.Time
+}
+
+func (self *clockTestCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	return self
+}
+
+func (self *clockTestCommand) Execute(ctx context.Context) int {
+	self.now = Now(ctx)
+	return 0
+}
+
+func TestWithClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cmd := &clockTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "now"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+
+	Invoke(context.Background(), root, []string{"tool", "now"}, WithClock(func() time.Time { return fixed }))
+	if !cmd.now.Equal(fixed) {
+		t.Errorf("Error. Expected: %s. Received: %s.", fixed, cmd.now)
+	}
+
+	before := time.Now()
+	Invoke(context.Background(), root, []string{"tool", "now"})
+	if cmd.now.Before(before) {
+		t.Errorf("Error. Expected the current time. Received: %s.", cmd.now)
+	}
+}
@@ -0,0 +1,288 @@
+package glarg
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// UPDATE_SCHEMA_ENV is the environment variable which makes
+// CheckCompatibility write the current schema instead of
+// comparing against it.
+const UPDATE_SCHEMA_ENV = "GLARG_UPDATE_SCHEMA"
+
+// Change is one difference between two versions of a command
+// tree. Breaking changes make command lines which worked with the
+// old version fail, or mean something else, with the new one.
+type Change struct {
+	Breaking bool
+	Path     []string
+	// Empty when the change is about the command itself.
+	Flag    string
+	Message string
+}
+
+func (self Change) String() string {
+	subject := strings.Join(self.Path, " ")
+	if self.Flag != "" {
+		subject += " -" + self.Flag
+	}
+	return subject + ": " + self.Message
+}
+
+// CompareSchemas returns the changes from before to after. Descriptions
+// and usage texts are not compared.
+func CompareSchemas(before Schema, after Schema) []Change {
+	return compareCommands(before.Command, after.Command, []Change{})
+}
+
+func compareCommands(before CommandInfo, after CommandInfo, changes []Change) []Change {
+	change := func(breaking bool, flag string, format string, args ...interface{}) {
+		changes = append(changes, Change{
+			Breaking: breaking,
+			Path:     after.Path,
+			Flag:     flag,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if before.Default != after.Default {
+		change(before.Default != "", "", "default command changed from %q to %q", before.Default, after.Default)
+	}
+
+	for _, v := range before.Flags {
+		f := after.Flag(v.Name)
+		if f == nil {
+			change(true, v.Name, "flag removed")
+			continue
+		}
+		if v.Type != f.Type || v.Target != f.Target || v.Element != f.Element {
+			change(true, v.Name, "type changed from %s to %s", v.typeText(), f.typeText())
+		} else if v.Delimiter != f.Delimiter {
+			change(true, v.Name, "delimiter changed from %q to %q", v.Delimiter, f.Delimiter)
+		}
+		if v.Boolean != f.Boolean {
+			change(true, v.Name, "boolean changed from %t to %t", v.Boolean, f.Boolean)
+		}
+		for _, c := range compareChoices(v.Choices, f.Choices) {
+			change(c.removed, v.Name, "choice %q %s", c.value, c.state())
+		}
+		if v.Default != f.Default {
+			change(false, v.Name, "default changed from %q to %q", v.Default, f.Default)
+		}
+	}
+	for _, v := range after.Flags {
+		if before.Flag(v.Name) == nil {
+			change(false, v.Name, "flag added")
+		}
+	}
+
+	// A constraint which is new, or changed, is breaking when it
+	// refuses command lines the ones before accepted, while
+	// dropping one only accepts more.
+	removed := []FlagConstraint{}
+	for _, v := range before.Constraints {
+		if !hasConstraint(after.Constraints, v) {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range after.Constraints {
+		if hasConstraint(before.Constraints, v) {
+			continue
+		}
+		breaking := !looserConstraint(v, before.Constraints)
+		replaced := -1
+		for i, r := range removed {
+			if r.Kind == v.Kind && r.If == v.If {
+				replaced = i
+				break
+			}
+		}
+		if replaced < 0 {
+			change(breaking, "", "constraint added: %s", constraintText(v))
+			continue
+		}
+		change(breaking, "", "constraint changed from %q to %q", strings.TrimSuffix(constraintText(removed[replaced]), "."), strings.TrimSuffix(constraintText(v), "."))
+		removed = append(removed[:replaced], removed[replaced+1:]...)
+	}
+	for _, v := range removed {
+		change(false, "", "constraint removed: %s", constraintText(v))
+	}
+
+	for _, v := range before.Children {
+		if c := after.child(v.Name); c != nil {
+			changes = compareCommands(v, *c, changes)
+		} else {
+			changes = append(changes, Change{Breaking: true, Path: v.Path, Message: "command removed"})
+		}
+	}
+	for _, v := range after.Children {
+		if before.child(v.Name) == nil {
+			changes = append(changes, Change{Path: v.Path, Message: "command added"})
+		}
+	}
+	return changes
+}
+
+func (self CommandInfo) child(name string) *CommandInfo {
+	for i, v := range self.Children {
+		if v.Name == name {
+			return &self.Children[i]
+		}
+	}
+	return nil
+}
+
+// hasConstraint is true when constraints hold c, with its flags in
+// any order.
+func hasConstraint(constraints []FlagConstraint, c FlagConstraint) bool {
+	for _, v := range constraints {
+		if v.Kind == c.Kind && v.If == c.If && isSubset(v.Flags, c.Flags) && isSubset(c.Flags, v.Flags) {
+			return true
+		}
+	}
+	return false
+}
+
+// looserConstraint is true when the constraints before already
+// refuse every command line c refuses. Required and Requires add
+// up, the other kinds are compared one by one: at most one of, and
+// all or none of, fewer flags, at least one of more flags, and
+// exactly one of the same flags.
+func looserConstraint(c FlagConstraint, before []FlagConstraint) bool {
+	required := []string{}
+	for _, v := range before {
+		if v.Kind != c.Kind || v.If != c.If {
+			continue
+		}
+		switch c.Kind {
+		case ConstraintRequired, ConstraintRequires:
+			required = append(required, v.Flags...)
+		case ConstraintAtLeastOneOf:
+			if isSubset(v.Flags, c.Flags) {
+				return true
+			}
+		case ConstraintExactlyOneOf:
+			if isSubset(v.Flags, c.Flags) && isSubset(c.Flags, v.Flags) {
+				return true
+			}
+		default:
+			if isSubset(c.Flags, v.Flags) {
+				return true
+			}
+		}
+	}
+	if c.Kind == ConstraintRequired || c.Kind == ConstraintRequires {
+		return isSubset(c.Flags, required)
+	}
+	return false
+}
+
+// isSubset is true when every name of a is in b.
+func isSubset(a []string, b []string) bool {
+	names := map[string]bool{}
+	for _, v := range b {
+		names[v] = true
+	}
+	for _, v := range a {
+		if !names[v] {
+			return false
+		}
+	}
+	return true
+}
+
+type choiceChange struct {
+	value   string
+	removed bool
+}
+
+func (self choiceChange) state() string {
+	if self.removed {
+		return "removed"
+	}
+	return "added"
+}
+
+func compareChoices(before []EnumChoice, after []EnumChoice) []choiceChange {
+	seen := map[string]bool{}
+	for _, v := range after {
+		seen[v.Value] = true
+	}
+	changes := []choiceChange{}
+	for _, v := range before {
+		if !seen[v.Value] {
+			changes = append(changes, choiceChange{v.Value, true})
+		}
+		delete(seen, v.Value)
+	}
+	for _, v := range after {
+		if seen[v.Value] {
+			changes = append(changes, choiceChange{v.Value, false})
+		}
+	}
+	return changes
+}
+
+// BreakingChanges returns only the breaking changes.
+func BreakingChanges(changes []Change) []Change {
+	breaking := []Change{}
+	for _, v := range changes {
+		if v.Breaking {
+			breaking = append(breaking, v)
+		}
+	}
+	return breaking
+}
+
+// TestingT is the part of testing.TB used by CheckCompatibility.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// CheckCompatibility compares the tree of root against the schema
+// kept in path, usually the one of the last release, and fails t
+// for every breaking change which is not listed in approved by its
+// String. The schema is written instead when it does not exist yet,
+// or when UPDATE_SCHEMA_ENV is set, which is how a release accepts
+// the changes. The tree must already be setup.
+func CheckCompatibility(t TestingT, path string, root Subcommand, approved ...string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) || os.Getenv(UPDATE_SCHEMA_ENV) != "" {
+		if err == nil {
+			f.Close()
+		}
+		if err := writeFile(path, func(w io.Writer) error {
+			return WriteSchema(w, root)
+		}); err != nil {
+			t.Errorf("Unable to write the schema. %s", err)
+		}
+		return
+	} else if err != nil {
+		t.Errorf("Unable to read the schema. %s", err)
+		return
+	}
+	defer f.Close()
+
+	before, err := ReadSchema(f)
+	if err != nil {
+		t.Errorf("Unable to read the schema. %s", err)
+		return
+	}
+
+	allowed := map[string]bool{}
+	for _, v := range approved {
+		allowed[v] = true
+	}
+	for _, v := range BreakingChanges(CompareSchemas(before, NewSchema(root))) {
+		if !allowed[v.String()] {
+			t.Errorf("Breaking change: %s", v)
+		}
+	}
+}
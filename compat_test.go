@@ -0,0 +1,157 @@
+package glarg
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type compatTestCommand struct {
+	SubcommandNoOp
+	ids     []string
+	format  string
+	force   bool
+	limit   int
+	changed bool
+}
+
+func (self *compatTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.format = "text"
+	if !self.changed {
+		self.flagSet.Var(NewSliceFlag(&StringSliceFlagTarget{&self.ids}, ""), "ids", "The ids.")
+		self.flagSet.Var(NewEnumFlag(&self.format, "text", "json"), "format", "The format.")
+		self.flagSet.BoolVar(&self.force, "force", false, "Do it.")
+		self.flagSet.IntVar(&self.limit, "limit", 10, "The limit.")
+	} else {
+		self.flagSet.Var(NewSliceFlag(&UUIDSliceFlagTarget{}, ""), "ids", "The ids.")
+		self.flagSet.Var(NewEnumFlag(&self.format, "text", "yaml"), "format", "The format.")
+		self.flagSet.IntVar(&self.limit, "limit", 20, "The limit.")
+		self.flagSet.BoolVar(&self.force, "dry-run", false, "Do not do it.")
+	}
+	return self
+}
+
+func (self *compatTestCommand) FlagConstraints() []FlagConstraint {
+	if self.changed {
+		return []FlagConstraint{Required("limit")}
+	}
+	return nil
+}
+
+func compatTestTree(changed bool) *Subcommands {
+	children := []Subcommand{&compatTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}, changed: changed}}
+	if changed {
+		children = append(children, &SubcommandNoOp{Name: "put"})
+	} else {
+		children = append(children, &SubcommandNoOp{Name: "delete"})
+	}
+	root := &Subcommands{Name: "tool", Children: children}
+	root.SetupSubcommand()
+	return root
+}
+
+func TestCompareSchemas(t *testing.T) {
+	changes := CompareSchemas(NewSchema(compatTestTree(false)), NewSchema(compatTestTree(true)))
+
+	received := []string{}
+	for _, v := range changes {
+		received = append(received, fmt.Sprintf("%t %s", v.Breaking, v))
+	}
+	expected := []string{
+		"true tool get -force: flag removed",
+		"true tool get -format: choice \"json\" removed",
+		"false tool get -format: choice \"yaml\" added",
+		"true tool get -ids: type changed from SliceFlag of string, separated by \",\" to SliceFlag of uuid.UUID, separated by \",\"",
+		"false tool get -limit: default changed from \"10\" to \"20\"",
+		"false tool get -dry-run: flag added",
+		"true tool get: constraint added: Required: -limit.",
+		"true tool delete: command removed",
+		"false tool put: command added",
+	}
+	if strings.Join(received, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Error. Expected:\n%s\nReceived:\n%s", strings.Join(expected, "\n"), strings.Join(received, "\n"))
+	}
+	if len(BreakingChanges(changes)) != 5 {
+		t.Errorf("Error. Expected: 5. Received: %d.", len(BreakingChanges(changes)))
+	}
+}
+
+type compatTestT struct {
+	errors []string
+}
+
+func (self *compatTestT) Helper() {}
+
+func (self *compatTestT) Errorf(format string, args ...interface{}) {
+	self.errors = append(self.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cli.json")
+
+	// The first run writes the schema.
+	ct := &compatTestT{}
+	CheckCompatibility(ct, path, compatTestTree(false))
+	if _, err := os.Stat(path); err != nil || len(ct.errors) > 0 {
+		t.Fatalf("Error. Expected the schema to be written. %v %v", err, ct.errors)
+	}
+
+	ct = &compatTestT{}
+	CheckCompatibility(ct, path, compatTestTree(true), "tool delete: command removed")
+	if len(ct.errors) != 4 {
+		t.Errorf("Error. Expected: 4. Received: %v.", ct.errors)
+	}
+	for _, v := range ct.errors {
+		if strings.Contains(v, "tool delete") {
+			t.Errorf("Error. Expected the approved change to pass. Received: %s.", v)
+		}
+	}
+
+	t.Setenv(UPDATE_SCHEMA_ENV, "1")
+	ct = &compatTestT{}
+	CheckCompatibility(ct, path, compatTestTree(true))
+	os.Unsetenv(UPDATE_SCHEMA_ENV)
+	CheckCompatibility(ct, path, compatTestTree(true))
+	if len(ct.errors) > 0 {
+		t.Errorf("Error. Expected the updated schema to pass. Received: %v.", ct.errors)
+	}
+}
+
+func TestCompareConstraints(t *testing.T) {
+	cases := []struct {
+		before   []FlagConstraint
+		after    []FlagConstraint
+		expected string
+	}{
+		{nil, []FlagConstraint{Required("a")}, "true constraint added: Required: -a."},
+		{[]FlagConstraint{Required("a", "b")}, []FlagConstraint{Required("b", "a")}, ""},
+		{[]FlagConstraint{Required("a", "b")}, []FlagConstraint{Required("a")}, "false constraint changed from \"Required: -a, -b\" to \"Required: -a\""},
+		{[]FlagConstraint{Required("a")}, []FlagConstraint{Required("a", "b")}, "true constraint changed from \"Required: -a\" to \"Required: -a, -b\""},
+		{[]FlagConstraint{Required("a"), Required("b")}, []FlagConstraint{Required("a", "b")}, "false constraint changed from \"Required: -a\" to \"Required: -a, -b\"|false constraint removed: Required: -b."},
+		{[]FlagConstraint{MutuallyExclusive("a", "b", "c")}, []FlagConstraint{MutuallyExclusive("a", "b")}, "false constraint changed from \"At most one of: -a, -b, -c\" to \"At most one of: -a, -b\""},
+		{[]FlagConstraint{MutuallyExclusive("a", "b")}, []FlagConstraint{MutuallyExclusive("a", "b", "c")}, "true constraint changed from \"At most one of: -a, -b\" to \"At most one of: -a, -b, -c\""},
+		{[]FlagConstraint{AtLeastOneOf("a", "b")}, []FlagConstraint{AtLeastOneOf("a", "b", "c")}, "false constraint changed from \"At least one of: -a, -b\" to \"At least one of: -a, -b, -c\""},
+		{[]FlagConstraint{AtLeastOneOf("a", "b")}, []FlagConstraint{AtLeastOneOf("a")}, "true constraint changed from \"At least one of: -a, -b\" to \"At least one of: -a\""},
+		{[]FlagConstraint{ExactlyOneOf("a", "b")}, []FlagConstraint{ExactlyOneOf("a", "b", "c")}, "true constraint changed from \"Exactly one of: -a, -b\" to \"Exactly one of: -a, -b, -c\""},
+		{[]FlagConstraint{Requires("a", "b", "c")}, []FlagConstraint{Requires("a", "b")}, "false constraint changed from \"-a requires: -b, -c\" to \"-a requires: -b\""},
+		{[]FlagConstraint{Requires("a", "b")}, []FlagConstraint{Requires("c", "b")}, "true constraint added: -c requires: -b.|false constraint removed: -a requires: -b."},
+		{[]FlagConstraint{Required("a")}, nil, "false constraint removed: Required: -a."},
+	}
+	for _, v := range cases {
+		changes := CompareSchemas(
+			Schema{Command: CommandInfo{Constraints: v.before}},
+			Schema{Command: CommandInfo{Constraints: v.after}},
+		)
+		received := []string{}
+		for _, c := range changes {
+			received = append(received, fmt.Sprintf("%t %s", c.Breaking, c.Message))
+		}
+		if strings.Join(received, "|") != v.expected {
+			t.Errorf("Error. Before: %v. After: %v. Expected: %s. Received: %s.", v.before, v.after, v.expected, strings.Join(received, "|"))
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Completer is implemented by flag values that know which
+// values they accept, such as the EnumFlag. It is used to
+// drive shell completion.
+type Completer interface {
+	Complete(prefix string) []string
+}
+
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// Complete returns the completion candidates for the last
+// element of args, which are the words typed after the
+// program name. The command tree must already be setup. Like
+// Execute, a Subcommands given only flags goes on to its Default,
+// so a flag is completed from the flags of both.
+func Complete(cmd Subcommand, args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+
+	if sc, ok := cmd.(*Subcommands); ok {
+		var def Subcommand
+		for _, v := range sc.Children {
+			if sc.Default != "" && v.FlagSet().Name() == sc.Default {
+				def = v
+			}
+		}
+
+		i := 0
+		for ; i < len(args)-1; i++ {
+			if f, value := flagAt(sc.FlagSet(), args, i); f != nil {
+				if value == len(args)-1 {
+					return completeFlagValue(f, current)
+				} else if value > i {
+					i = value
+				}
+				continue
+			} else if strings.HasPrefix(args[i], "-") {
+				break
+			}
+			for _, v := range sc.Children {
+				if v.FlagSet().Name() == args[i] {
+					return Complete(v, args[i+1:])
+				}
+			}
+			return []string{}
+		}
+
+		// A flag of the Default.
+		if i < len(args)-1 {
+			if def == nil {
+				return []string{}
+			}
+			return Complete(def, args[i:])
+		}
+
+		matches := []string{}
+		if strings.HasPrefix(current, "-") {
+			if sc.FlagSet() != nil {
+				matches = append(matches, completeFlags(sc.FlagSet(), args[i:])...)
+			}
+			if def != nil && def.FlagSet() != nil {
+				matches = append(matches, completeFlags(def.FlagSet(), args[i:])...)
+			}
+			return matches
+		}
+		for _, v := range sc.Children {
+			if strings.HasPrefix(v.FlagSet().Name(), current) {
+				matches = append(matches, v.FlagSet().Name())
+			}
+		}
+		for _, v := range sc.FindPlugins() {
+			if strings.HasPrefix(v.Name, current) {
+				matches = append(matches, v.Name)
+			}
+		}
+		return matches
+	}
+
+	if cmd.FlagSet() == nil {
+		return []string{}
+	}
+	return completeFlags(cmd.FlagSet(), args)
+}
+
+func completeFlags(fs *flag.FlagSet, args []string) []string {
+	current := args[len(args)-1]
+
+	// -name=value
+	if strings.HasPrefix(current, "-") && strings.Contains(current, "=") {
+		pieces := strings.SplitN(current, "=", 2)
+		matches := completeFlagValue(fs.Lookup(strings.TrimLeft(pieces[0], "-")), pieces[1])
+		for i, v := range matches {
+			matches[i] = pieces[0] + "=" + v
+		}
+		return matches
+	}
+
+	// -name
+	if strings.HasPrefix(current, "-") {
+		dashes := "-"
+		if strings.HasPrefix(current, "--") {
+			dashes = "--"
+		}
+		prefix := strings.TrimLeft(current, "-")
+		matches := []string{}
+		fs.VisitAll(func(f *flag.Flag) {
+			if strings.HasPrefix(f.Name, prefix) {
+				matches = append(matches, dashes+f.Name)
+			}
+		})
+		return matches
+	}
+
+	// -name value
+	if len(args) > 1 {
+		previous := args[len(args)-2]
+		if strings.HasPrefix(previous, "-") && !strings.Contains(previous, "=") {
+			f := fs.Lookup(strings.TrimLeft(previous, "-"))
+			if f != nil {
+				if bf, ok := f.Value.(boolFlag); !ok || !bf.IsBoolFlag() {
+					return completeFlagValue(f, current)
+				}
+			}
+		}
+	}
+
+	return []string{}
+}
+
+func completeFlagValue(f *flag.Flag, prefix string) []string {
+	if f == nil {
+		return []string{}
+	}
+	if c, ok := f.Value.(Completer); ok {
+		return c.Complete(prefix)
+	}
+	return []string{}
+}
+
+// CompletionCommand is a Subcommand which prints the completion
+// candidates for the words after "--", one per line. Add it as a
+// child of the root and source the output of BashCompletionScript.
+type CompletionCommand struct {
+	flagSet *flag.FlagSet
+	args    []string
+	Name    string
+	Root    Subcommand
+}
+
+func (self *CompletionCommand) Description() string {
+	return "Print shell completion candidates."
+}
+
+func (self *CompletionCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *CompletionCommand) SetupSubcommand() Subcommand {
+	// The words being completed are usually not valid flags of
+	// this command, so errors are quietly ignored.
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ContinueOnError)
+	self.flagSet.SetOutput(io.Discard)
+	return self
+}
+
+func (self *CompletionCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *CompletionCommand) SetArgs(args []string) {
+	self.args = args
+}
+
+func (self *CompletionCommand) Execute(ctx context.Context) int {
+	words := []string{}
+	for i, v := range self.args {
+		if v == "--" {
+			words = self.args[i+1:]
+			break
+		}
+	}
+
+	for _, v := range Complete(self.Root, words) {
+		fmt.Fprintln(Env(ctx).Stdout, v)
+	}
+	return 0
+}
+
+// BashCompletionScript returns a bash completion script for prog
+// which calls the CompletionCommand with the given name.
+func BashCompletionScript(prog string, name string) string {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog) + "_complete"
+	return fmt.Sprintf(`%[1]s() {
+    local IFS=$'\n'
+    COMPREPLY=( $(%[2]s %[3]s -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) )
+}
+complete -o default -F %[1]s %[2]s
+`, fn, prog, name)
+}
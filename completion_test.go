@@ -0,0 +1,118 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+)
+
+type completionTestCommand struct {
+	SubcommandNoOp
+	output string
+}
+
+func (self *completionTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewEnumFlag(&self.output, "json", "yaml", "table"), "output", "output format")
+	self.flagSet.Bool("verbose", false, "verbose output")
+	return self
+}
+
+func TestComplete(t *testing.T) {
+	list := &completionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{list, &SubcommandNoOp{Name: "login"}, &SubcommandNoOp{Name: "purge"}},
+	}
+	root.SetupSubcommand()
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{""}, "list login purge"},
+		{[]string{"l"}, "list login"},
+		{[]string{"list", "-"}, "-output -verbose"},
+		{[]string{"list", "--o"}, "--output"},
+		{[]string{"list", "-output", "j"}, "json"},
+		{[]string{"list", "-output="}, "-output=json -output=yaml -output=table"},
+		{[]string{"list", "-verbose", ""}, ""},
+		{[]string{"unknown", ""}, ""},
+	}
+	for _, v := range cases {
+		received := strings.Join(Complete(root, v.args), " ")
+		if received != v.expected {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %s.", v.args, v.expected, received)
+		}
+	}
+}
+
+func TestCompleteDefault(t *testing.T) {
+	list := &completionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}
+	root := &Subcommands{
+		Name:     "root",
+		Default:  "list",
+		Children: []Subcommand{list, &SubcommandNoOp{Name: "login"}},
+	}
+	root.SetupSubcommand()
+	root.FlagSet().String("profile", "", "profile")
+	root.FlagSet().Var(NewEnumFlag(new(string), "dev", "prod"), "stage", "stage")
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-"}, "-profile -stage -output -verbose"},
+		{[]string{"--o"}, "--output"},
+		{[]string{"-output", "t"}, "table"},
+		{[]string{"-verbose", "-output="}, "-output=json -output=yaml -output=table"},
+		{[]string{"-stage", "p"}, "prod"},
+		{[]string{"-stage=d"}, "-stage=dev"},
+		{[]string{"-profile", "x", "l"}, "list login"},
+		{[]string{"-profile", "x", "-v"}, "-verbose"},
+		{[]string{"-profile", "x", "list", "-"}, "-output -verbose"},
+		{[]string{"login", "-"}, ""},
+	}
+	for _, v := range cases {
+		received := strings.Join(Complete(root, v.args), " ")
+		if received != v.expected {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %s.", v.args, v.expected, received)
+		}
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{&SubcommandNoOp{Name: "empty"}, &completionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}},
+	}
+	root.Children = append(root.Children, &CompletionCommand{Name: "__complete", Root: root})
+
+	cases := []struct {
+		words    []string
+		expected string
+	}{
+		{[]string{"e"}, "empty\n"},
+		{[]string{""}, "empty\nlist\n__complete\n"},
+		{[]string{"list", "-output", "y"}, "yaml\n"},
+		{[]string{"missing", ""}, ""},
+	}
+	for _, v := range cases {
+		var stdout bytes.Buffer
+		env := &Environment{Stdout: &stdout}
+		rc := Invoke(context.Background(), root, append([]string{"cmd", "__complete", "--"}, v.words...), WithEnvironment(env))
+		if rc != 0 {
+			t.Errorf("Error. Expected: 0. Received: %d.", rc)
+		}
+		if stdout.String() != v.expected {
+			t.Errorf("Error. Words: %q. Expected: %q. Received: %q.", v.words, v.expected, stdout.String())
+		}
+	}
+
+	script := BashCompletionScript("my-tool", "__complete")
+	if !strings.Contains(script, "complete -o default -F _my_tool_complete my-tool") {
+		t.Errorf("Error. Unexpected script. Received: %s", script)
+	}
+}
@@ -0,0 +1,198 @@
+package glarg
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// FlagConstrainer is implemented by a Subcommand which declares
+// how its flags relate to each other. Subcommands checks the
+// constraints after parsing and before UnpackArgs, and reports
+// every violation together.
+type FlagConstrainer interface {
+	FlagConstraints() []FlagConstraint
+}
+
+type ConstraintKind int
+
+const (
+	// Every flag must be set.
+	ConstraintRequired ConstraintKind = iota
+	// At most one of the flags can be set.
+	ConstraintMutuallyExclusive
+	// Either all or none of the flags must be set.
+	ConstraintRequiredTogether
+	// At least one of the flags must be set.
+	ConstraintAtLeastOneOf
+	// Exactly one of the flags must be set.
+	ConstraintExactlyOneOf
+	// When the If flag is set, every flag must be set.
+	ConstraintRequires
+)
+
+func (self ConstraintKind) String() string {
+	switch self {
+	case ConstraintRequired:
+		return "required"
+	case ConstraintMutuallyExclusive:
+		return "mutually_exclusive"
+	case ConstraintRequiredTogether:
+		return "required_together"
+	case ConstraintAtLeastOneOf:
+		return "at_least_one_of"
+	case ConstraintExactlyOneOf:
+		return "exactly_one_of"
+	case ConstraintRequires:
+		return "requires"
+	}
+	return fmt.Sprintf("ConstraintKind(%d)", int(self))
+}
+
+func (self ConstraintKind) MarshalText() ([]byte, error) {
+	return []byte(self.String()), nil
+}
+
+func (self *ConstraintKind) UnmarshalText(text []byte) error {
+	for k := ConstraintRequired; k <= ConstraintRequires; k++ {
+		if k.String() == string(text) {
+			*self = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown constraint kind %q", text)
+}
+
+// FlagConstraint is a declarative rule about which flags of
+// a FlagSet must, or must not, be set together. Use the
+// constructors below to create them.
+type FlagConstraint struct {
+	Kind  ConstraintKind `json:"kind"`
+	If    string         `json:"if,omitempty"`
+	Flags []string       `json:"flags"`
+}
+
+func Required(names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintRequired, Flags: names}
+}
+
+func MutuallyExclusive(names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintMutuallyExclusive, Flags: names}
+}
+
+func RequiredTogether(names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintRequiredTogether, Flags: names}
+}
+
+func AtLeastOneOf(names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintAtLeastOneOf, Flags: names}
+}
+
+func ExactlyOneOf(names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintExactlyOneOf, Flags: names}
+}
+
+// Requires makes the flags in names required when name is set,
+// for example Requires("cert", "key").
+func Requires(name string, names ...string) FlagConstraint {
+	return FlagConstraint{Kind: ConstraintRequires, If: name, Flags: names}
+}
+
+func flagNames(names []string) string {
+	dashed := make([]string, len(names))
+	for i, v := range names {
+		dashed[i] = "-" + v
+	}
+	if len(dashed) < 2 {
+		return strings.Join(dashed, "")
+	}
+	return strings.Join(dashed[:len(dashed)-1], ", ") + " and " + dashed[len(dashed)-1]
+}
+
+func flagAlternatives(names []string) string {
+	return strings.Replace(flagNames(names), " and ", " or ", 1)
+}
+
+// Check returns an error describing the violation, if any. Only
+// flags explicitly set on the command line count as set.
+func (self FlagConstraint) Check(fs *flag.FlagSet) error {
+	_, err := self.check(fs)
+	return err
+}
+
+// check is Check, which also returns the flags the violation is
+// about.
+func (self FlagConstraint) check(fs *flag.FlagSet) ([]string, error) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for _, v := range append([]string{self.If}, self.Flags...) {
+		if v != "" && fs.Lookup(v) == nil {
+			return []string{v}, fmt.Errorf("constraint on unknown flag -%s", v)
+		}
+	}
+
+	isSet, notSet := []string{}, []string{}
+	for _, v := range self.Flags {
+		if set[v] {
+			isSet = append(isSet, v)
+		} else {
+			notSet = append(notSet, v)
+		}
+	}
+
+	switch self.Kind {
+	case ConstraintRequired:
+		if len(notSet) == 1 {
+			return notSet, fmt.Errorf("%s is required", flagNames(notSet))
+		} else if len(notSet) > 1 {
+			return notSet, fmt.Errorf("%s are required", flagNames(notSet))
+		}
+	case ConstraintMutuallyExclusive:
+		if len(isSet) > 1 {
+			return isSet, fmt.Errorf("%s can not be used together", flagNames(isSet))
+		}
+	case ConstraintRequiredTogether:
+		if len(isSet) > 0 && len(notSet) > 0 {
+			return notSet, fmt.Errorf("%s must be used together, missing %s", flagNames(self.Flags), flagNames(notSet))
+		}
+	case ConstraintAtLeastOneOf:
+		if len(isSet) == 0 {
+			return self.Flags, fmt.Errorf("at least one of %s is required", flagAlternatives(self.Flags))
+		}
+	case ConstraintExactlyOneOf:
+		if len(isSet) == 0 {
+			return self.Flags, fmt.Errorf("one of %s is required", flagAlternatives(self.Flags))
+		} else if len(isSet) > 1 {
+			return isSet, fmt.Errorf("only one of %s can be used", flagAlternatives(self.Flags))
+		}
+	case ConstraintRequires:
+		if set[self.If] && len(notSet) > 0 {
+			return append([]string{self.If}, notSet...), fmt.Errorf("-%s requires %s", self.If, flagNames(notSet))
+		}
+	default:
+		return self.Flags, fmt.Errorf("unknown constraint kind %s", self.Kind)
+	}
+	return nil, nil
+}
+
+// CheckFlagConstraints checks every constraint against fs and
+// returns all of the violations, with the flags each one is about.
+func CheckFlagConstraints(fs *flag.FlagSet, constraints []FlagConstraint) []FieldError {
+	errs := []FieldError{}
+	if fs == nil {
+		return errs
+	}
+	for _, v := range constraints {
+		if names, err := v.check(fs); err != nil {
+			fe := FieldError{Flags: names, Message: err.Error()}
+			if len(names) > 0 {
+				fe.Flag = names[0]
+			}
+			errs = append(errs, fe)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,102 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func constraintTestFlagSet(args ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet("constraints", flag.ContinueOnError)
+	for _, v := range []string{"id", "url", "cert", "key", "name"} {
+		fs.String(v, "", v)
+	}
+	fs.Parse(args)
+	return fs
+}
+
+func TestFlagConstraint(t *testing.T) {
+	cases := []struct {
+		constraint FlagConstraint
+		args       []string
+		expected   string
+	}{
+		{Required("name"), []string{"-name", "x"}, ""},
+		{Required("name"), []string{}, "-name is required"},
+		{Required("name", "id"), []string{}, "-name and -id are required"},
+		{MutuallyExclusive("id", "url"), []string{"-id", "x"}, ""},
+		{MutuallyExclusive("id", "url"), []string{}, ""},
+		{MutuallyExclusive("id", "url"), []string{"-id", "x", "-url", "y"}, "-id and -url can not be used together"},
+		{RequiredTogether("cert", "key"), []string{}, ""},
+		{RequiredTogether("cert", "key"), []string{"-cert", "a", "-key", "b"}, ""},
+		{RequiredTogether("cert", "key"), []string{"-key", "b"}, "-cert and -key must be used together, missing -cert"},
+		{AtLeastOneOf("id", "url", "name"), []string{"-url", "x"}, ""},
+		{AtLeastOneOf("id", "url", "name"), []string{}, "at least one of -id, -url or -name is required"},
+		{ExactlyOneOf("id", "url"), []string{"-url", "x"}, ""},
+		{ExactlyOneOf("id", "url"), []string{}, "one of -id or -url is required"},
+		{ExactlyOneOf("id", "url"), []string{"-id", "x", "-url", "y"}, "only one of -id or -url can be used"},
+		{Requires("cert", "key"), []string{}, ""},
+		{Requires("cert", "key"), []string{"-key", "b"}, ""},
+		{Requires("cert", "key"), []string{"-cert", "a"}, "-cert requires -key"},
+		{Required("missing"), []string{}, "constraint on unknown flag -missing"},
+		// Set to the default value still counts as set.
+		{Required("name"), []string{"-name", ""}, ""},
+	}
+	for _, v := range cases {
+		err := v.constraint.Check(constraintTestFlagSet(v.args...))
+		if v.expected == "" && err != nil {
+			t.Errorf("Error. %s %v: expected no error. Received: %s", v.constraint.Kind, v.args, err)
+		} else if v.expected != "" && (err == nil || err.Error() != v.expected) {
+			t.Errorf("Error. %s %v: expected: %s. Received: %v.", v.constraint.Kind, v.args, v.expected, err)
+		}
+	}
+}
+
+type constraintTestCommand struct {
+	SubcommandNoOp
+}
+
+func (self *constraintTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.String("id", "", "id")
+	self.flagSet.String("url", "", "url")
+	self.flagSet.String("cert", "", "cert")
+	self.flagSet.String("key", "", "key")
+	return self
+}
+
+func (self *constraintTestCommand) FlagConstraints() []FlagConstraint {
+	return []FlagConstraint{
+		ExactlyOneOf("id", "url"),
+		Requires("cert", "key"),
+	}
+}
+
+func TestSubcommandsFlagConstraints(t *testing.T) {
+	cmd := &constraintTestCommand{SubcommandNoOp{Name: "get"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+
+	fs := constraintTestFlagSet("-cert", "a")
+	errs := CheckFlagConstraints(fs, cmd.FlagConstraints())
+	if len(errs) != 2 {
+		t.Fatalf("Error. Expected: 2. Received: %v.", errs)
+	}
+	for i, v := range []string{"id url", "cert key"} {
+		if errs[i].Flag != errs[i].Flags[0] || strings.Join(errs[i].Flags, " ") != v {
+			t.Errorf("Error. Expected: %s. Received: %s %v.", v, errs[i].Flag, errs[i].Flags)
+		}
+	}
+	if errs[1].Error() != "-cert requires -key" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "-cert requires -key", errs[1].Error())
+	}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "get", "-id", "x"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	rc = Invoke(context.Background(), root, []string{"tool", "get", "-cert", "a"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
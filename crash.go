@@ -0,0 +1,239 @@
+package glarg
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const (
+	// Returned by Invoke when a command panics and RecoverPanics
+	// is used. Same as EX_SOFTWARE from sysexits.h.
+	EXIT_PANIC = 70
+)
+
+// Flags whose name contains one of these are redacted from
+// crash reports, as are all of the SecretFlags.
+var sensitiveFlagNames = []string{"password", "passwd", "secret", "token", "key", "credential", "auth"}
+
+// RecoverPanics makes Invoke recover a panic in the command,
+// write a crash report to dir and return EXIT_PANIC. When dir is
+// empty the report goes into the user's cache directory.
+func RecoverPanics(dir string) InvokeOption {
+	return func(self *invocation) {
+		self.recoverPanics = true
+		self.crashDir = dir
+	}
+}
+
+func (self *invocation) reportPanic(cmd Subcommand, args []string, r interface{}) int {
+	stack := debug.Stack()
+	prog := "glarg"
+	if len(args) > 0 {
+		prog = filepath.Base(args[0])
+	}
+
+	report := buildCrashReport(cmd, args, r, stack)
+	if path, err := self.writeCrashReport(prog, report); err != nil {
+		self.env.logf(slog.LevelError, "%s crashed unexpectedly: %v. The crash report could not be written: %s", prog, r, err)
+	} else {
+		self.env.logf(slog.LevelError, "%s crashed unexpectedly. A crash report was written to %s", prog, path)
+	}
+	return EXIT_PANIC
+}
+
+func (self *invocation) writeCrashReport(prog string, report string) (string, error) {
+	dir := self.crashDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cache, prog, "crash")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(dir, fmt.Sprintf("crash-%s-*.txt", time.Now().UTC().Format("20060102T150405Z")))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(report); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func buildCrashReport(cmd Subcommand, args []string, r interface{}, stack []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Time: %s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Command: %s\n", strings.Join(commandPathFromArgs(cmd, args), " "))
+	fmt.Fprintf(&b, "Args: %q\n", RedactArgs(cmd, args))
+	fmt.Fprintf(&b, "Go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(&b, "Module: %s %s\n", info.Main.Path, info.Main.Version)
+		for _, v := range info.Settings {
+			if strings.HasPrefix(v.Key, "vcs.") {
+				fmt.Fprintf(&b, "Build: %s=%s\n", v.Key, v.Value)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "Panic: %v\n\n%s", r, stack)
+	return b.String()
+}
+
+// findCommand follows args, which start with the program name,
+// down the tree. It returns the command they are for, the names
+// of the commands matched, root first, and the index of the
+// first argument left for that command. The flags of the
+// Subcommands on the way are skipped, and given to fn when it is
+// not nil, see flagAt. Like Execute, a Subcommands given no name,
+// or only flags, goes on to its Default.
+func findCommand(cmd Subcommand, args []string, fn func(f *flag.Flag, i int, value int)) (Subcommand, []string, int) {
+	path := []string{}
+	if cmd.FlagSet() != nil {
+		path = append(path, cmd.FlagSet().Name())
+	}
+
+	i := 1
+	for {
+		sc, ok := cmd.(*Subcommands)
+		if !ok || (i < len(args) && args[i] == "--") {
+			break
+		}
+		if i < len(args) {
+			if f, value := flagAt(sc.FlagSet(), args, i); f != nil {
+				if fn != nil {
+					fn(f, i, value)
+				}
+				if value > i {
+					i = value
+				}
+				i++
+				continue
+			}
+		}
+
+		name, given := sc.Default, i < len(args) && !strings.HasPrefix(args[i], "-")
+		if given {
+			name = args[i]
+		}
+		var next Subcommand
+		for _, child := range sc.Children {
+			if name != "" && child.FlagSet() != nil && child.FlagSet().Name() == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		if given {
+			i++
+		}
+		path = append(path, name)
+		cmd = next
+	}
+	return cmd, path, i
+}
+
+// flagAt returns the flag of fs which args[i] names, or nil, and
+// the index of its value: i for -flag=value, and -1 for a boolean
+// flag or a missing value.
+func flagAt(fs *flag.FlagSet, args []string, i int) (*flag.Flag, int) {
+	v := args[i]
+	if fs == nil || !strings.HasPrefix(v, "-") || v == "-" || v == "--" {
+		return nil, -1
+	}
+	name, _, hasValue := strings.Cut(strings.TrimLeft(v, "-"), "=")
+	f := fs.Lookup(name)
+	if f == nil {
+		return nil, -1
+	} else if hasValue {
+		return f, i
+	}
+	if bf, ok := f.Value.(boolFlag); (ok && bf.IsBoolFlag()) || i+1 >= len(args) {
+		return f, -1
+	}
+	return f, i + 1
+}
+
+// undeclaredFlagAt is flagAt for the flags which are not declared,
+// like the ones given to a plugin. Such a flag takes the next
+// argument as its value, unless it is a flag too.
+func undeclaredFlagAt(args []string, i int) (*flag.Flag, int) {
+	v := args[i]
+	if !strings.HasPrefix(v, "-") || v == "-" || v == "--" {
+		return nil, -1
+	}
+	name, _, hasValue := strings.Cut(strings.TrimLeft(v, "-"), "=")
+	f := &flag.Flag{Name: name}
+	if hasValue {
+		return f, i
+	} else if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+		return f, i + 1
+	}
+	return f, -1
+}
+
+func commandPathFromArgs(cmd Subcommand, args []string) []string {
+	_, path, _ := findCommand(cmd, args, nil)
+	return path
+}
+
+// RedactArgs returns a copy of args with the values of secret
+// flags replaced by REDACTED, for the command they are for and
+// the Subcommands above it. A flag is secret when its value is a
+// SecretFlag or its name looks like it holds a password, token or
+// key, which is all there is to go on for the flags of plugins and
+// unknown commands. The command tree must already be setup.
+func RedactArgs(cmd Subcommand, args []string) []string {
+	redacted := append([]string{}, args...)
+	redact := func(f *flag.Flag, i int, value int) {
+		if value < 0 || !sensitiveFlag(f.Name, f.Value) {
+			return
+		}
+		if value == i {
+			redacted[i] = args[i][:strings.Index(args[i], "=")+1] + REDACTED
+		} else {
+			redacted[value] = REDACTED
+		}
+	}
+
+	cmd, _, i := findCommand(cmd, args, redact)
+	fs := cmd.FlagSet()
+	for ; i < len(args) && args[i] != "--"; i++ {
+		f, value := flagAt(fs, args, i)
+		if f == nil {
+			f, value = undeclaredFlagAt(args, i)
+		}
+		if f != nil {
+			redact(f, i, value)
+			if value > i {
+				i = value
+			}
+		}
+	}
+	return redacted
+}
+
+func sensitiveFlag(name string, value interface{}) bool {
+	if _, ok := value.(*SecretFlag); ok {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, v := range sensitiveFlagNames {
+		if strings.Contains(name, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type panicTestCommand struct {
+	SubcommandNoOp
+	token  Secret
+	apiKey string
+	user   string
+}
+
+func (self *panicTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewSecretFlag(&self.token).AllowLiteral(), "t", "token")
+	self.flagSet.StringVar(&self.apiKey, "api-key", "", "API key")
+	self.flagSet.StringVar(&self.user, "user", "", "user name")
+	return self
+}
+
+func (self *panicTestCommand) Execute(ctx context.Context) int {
+	panic("kaboom")
+}
+
+func TestRedactArgs(t *testing.T) {
+	cmd := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	root.SetupSubcommand()
+
+	args := []string{"tool", "boom", "-user", "bob", "-t", "hunter2", "--api-key=abc", "--", "-t", "positional"}
+	expected := []string{"tool", "boom", "-user", "bob", "-t", REDACTED, "--api-key=" + REDACTED, "--", "-t", "positional"}
+	received := RedactArgs(root, args)
+	if strings.Join(received, " ") != strings.Join(expected, " ") {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, received)
+	}
+	if args[5] != "hunter2" {
+		t.Errorf("Error. Expected the args to be left alone.")
+	}
+}
+
+func TestRedactArgsNested(t *testing.T) {
+	var creds Secret
+	cmd := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	nested := &Subcommands{Name: "nested", Children: []Subcommand{cmd}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{nested}}
+	root.SetupSubcommand()
+	nested.FlagSet().Var(NewSecretFlag(&creds), "creds", "credentials")
+	nested.FlagSet().String("region", "", "region")
+	nested.FlagSet().Bool("verbose", false, "verbose")
+
+	args := []string{"tool", "nested", "-creds", "@creds", "-region", "boom", "-verbose", "boom", "-t", "hunter2"}
+	expected := []string{"tool", "nested", "-creds", REDACTED, "-region", "boom", "-verbose", "boom", "-t", REDACTED}
+	received := RedactArgs(root, args)
+	if strings.Join(received, " ") != strings.Join(expected, " ") {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, received)
+	}
+	if path := commandPathFromArgs(root, args); strings.Join(path, " ") != "root nested boom" {
+		t.Errorf("Error. Expected: %s. Received: %v.", "root nested boom", path)
+	}
+}
+
+func TestRedactArgsPlugin(t *testing.T) {
+	root := &Subcommands{Name: "root", Children: []Subcommand{&panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}}}
+	root.SetupSubcommand()
+
+	args := []string{"tool", "myplugin", "-token", "abc", "--password=def", "-v", "-user", "bob", "file"}
+	expected := []string{"tool", "myplugin", "-token", REDACTED, "--password=" + REDACTED, "-v", "-user", "bob", "file"}
+	received := RedactArgs(root, args)
+	if strings.Join(received, " ") != strings.Join(expected, " ") {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, received)
+	}
+}
+
+func TestRedactArgsDefault(t *testing.T) {
+	cmd := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	root := &Subcommands{Name: "root", Default: "boom", Children: []Subcommand{cmd}}
+	root.SetupSubcommand()
+
+	for _, args := range [][]string{{"tool", "-t", "hunter2"}, {"tool", "boom", "-t", "hunter2"}} {
+		received := RedactArgs(root, args)
+		if received[len(received)-1] != REDACTED {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %q.", args, REDACTED, received)
+		}
+		if path := commandPathFromArgs(root, args); strings.Join(path, " ") != "root boom" {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %v.", args, "root boom", path)
+		}
+	}
+	if path := commandPathFromArgs(root, []string{"tool"}); strings.Join(path, " ") != "root boom" {
+		t.Errorf("Error. Expected: %s. Received: %v.", "root boom", path)
+	}
+
+	// The crash report of a command run by default is redacted too.
+	dir := t.TempDir()
+	root = &Subcommands{Name: "root", Default: "boom", Children: []Subcommand{cmd}}
+	if rc := Invoke(context.Background(), root, []string{"tool", "-t", "hunter2"}, RecoverPanics(dir)); rc != EXIT_PANIC {
+		t.Errorf("Error. Expected: %d. Received: %d.", EXIT_PANIC, rc)
+	}
+	reports, _ := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if len(reports) != 1 {
+		t.Fatalf("Error. Expected 1 crash report. Received: %v.", reports)
+	}
+	content, _ := os.ReadFile(reports[0])
+	if strings.Contains(string(content), "hunter2") || !strings.Contains(string(content), "Command: root boom\n") {
+		t.Errorf("Error. Expected the default command and no secret. Received: %s", content)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	dir := t.TempDir()
+	cmd := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "boom", "-t", "hunter2"}, RecoverPanics(dir))
+	if rc != EXIT_PANIC {
+		t.Errorf("Error. Expected: %d. Received: %d.", EXIT_PANIC, rc)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(dir, "crash-*.txt"))
+	if len(reports) != 1 {
+		t.Fatalf("Error. Expected 1 crash report. Received: %v.", reports)
+	}
+	content, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(content)
+	for _, v := range []string{"Command: root boom\n", "Panic: kaboom\n", REDACTED, "Go: go", "panicTestCommand"} {
+		if !strings.Contains(report, v) {
+			t.Errorf("Error. Expected the report to contain %q. Received: %s", v, report)
+		}
+	}
+	if strings.Contains(report, "hunter2") {
+		t.Errorf("Error. The secret leaked into the crash report: %s", report)
+	}
+
+	// Without the option the panic is not recovered.
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Error. Expected a panic.")
+		}
+	}()
+	Invoke(context.Background(), root, []string{"tool", "boom"})
+}
@@ -0,0 +1,113 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Confirmation is what the user agrees to before a Destructive
+// command runs.
+type Confirmation struct {
+	// The question, like "Purge the volume 0b6f…?". Defaults to
+	// asking to run the command.
+	Message string
+	// When set, the user types it instead of answering yes. Use it
+	// for the most dangerous commands, with the name or the id of
+	// what is about to be destroyed.
+	Typed string
+}
+
+// Destructive is implemented by a Subcommand which changes or
+// deletes things for good. Commands below a Subcommands which
+// implement it get -yes and -dry-run. Unless one is given, the
+// user confirms before the command runs, and when stdin is not a
+// terminal the command is refused. Confirm is called after the
+// arguments are validated and unpacked, so it can name what is
+// destroyed, and after the Middleware and the PreRun hooks, so
+// that a command they stop never asks.
+//
+// With -dry-run the command runs without a confirmation, and
+// DryRun tells Execute to only report what it would do.
+type Destructive interface {
+	Confirm() Confirmation
+}
+
+type dryRunKey struct{}
+
+// DryRun is true when the command was given -dry-run.
+func DryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// addDestructiveFlags adds -yes and -dry-run to the Destructive
+// commands of the tree. See addFlags.
+func addDestructiveFlags(cmd Subcommand) {
+	addFlags(cmd, func(cmd Subcommand, fs *flag.FlagSet) {
+		if _, ok := cmd.(Destructive); ok {
+			fs.Bool("yes", false, "Do not ask for a confirmation.")
+			fs.Bool("dry-run", false, "Only show what would be done.")
+		}
+	})
+}
+
+func flagIsTrue(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	return f != nil && f.Value.String() == "true"
+}
+
+// withDryRun returns ctx with DryRun set when cmd was given
+// -dry-run.
+func withDryRun(ctx context.Context, cmd Subcommand) context.Context {
+	if _, ok := cmd.(Destructive); ok && flagIsTrue(cmd.FlagSet(), "dry-run") {
+		return context.WithValue(ctx, dryRunKey{}, true)
+	}
+	return ctx
+}
+
+// confirm returns false when the user, or the lack of one, does not
+// let cmd run.
+func confirm(ctx context.Context, env *Environment, cmd Subcommand) bool {
+	if DryRun(ctx) || flagIsTrue(cmd.FlagSet(), "yes") {
+		return true
+	}
+
+	c := cmd.(Destructive).Confirm()
+	if c.Message == "" {
+		c.Message = fmt.Sprintf("Run %s?", strings.Join(CommandPath(ctx), " "))
+	}
+	p, ok := newPrompter(env)
+	if !ok {
+		env.logf(slog.LevelError, "%s Refusing to run without -yes when not on a terminal.", c.Message)
+		return false
+	}
+	if ok, err := p.confirm(c); err != nil || !ok {
+		env.logf(slog.LevelError, "Cancelled.")
+		return false
+	}
+	return true
+}
+
+// confirm asks the question of c, and returns true when the user
+// answered yes, or typed the text.
+func (self *prompter) confirm(c Confirmation) (bool, error) {
+	if c.Typed != "" {
+		fmt.Fprintf(self.out, "%s\nType %s to confirm: ", self.styler.Style(STYLE_WARNING, c.Message), self.styler.Style(STYLE_COMMAND, c.Typed))
+	} else {
+		fmt.Fprintf(self.out, "%s [y/N]: ", self.styler.Style(STYLE_WARNING, c.Message))
+	}
+
+	line, err := self.in.ReadString('\n')
+	if err != nil && line == "" {
+		return false, err
+	}
+	answer := strings.TrimSpace(line)
+	if c.Typed != "" {
+		return answer == c.Typed, nil
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,120 @@
+package glarg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+)
+
+type destructiveTestCommand struct {
+	SubcommandNoOp
+	ran    bool
+	dryRun bool
+}
+
+func (self *destructiveTestCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	return self
+}
+
+func (self *destructiveTestCommand) Execute(ctx context.Context) int {
+	self.ran = true
+	self.dryRun = DryRun(ctx)
+	return 0
+}
+
+func (self *destructiveTestCommand) Confirm() Confirmation {
+	return Confirmation{}
+}
+
+func TestDestructive(t *testing.T) {
+	cases := []struct {
+		args   []string
+		rc     int
+		ran    bool
+		dryRun bool
+		log    string
+	}{
+		{[]string{}, 1, false, false, "Run root purge? Refusing to run without -yes when not on a terminal."},
+		{[]string{"-yes"}, 0, true, false, ""},
+		{[]string{"-dry-run"}, 0, true, true, ""},
+	}
+	for _, v := range cases {
+		var logs bytes.Buffer
+		cmd := &destructiveTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "purge"}}
+		root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+		env := &Environment{Stdin: strings.NewReader("yes\n"), Logger: log.New(&logs, "", 0)}
+		if rc := Invoke(context.Background(), root, append([]string{"tool", "purge"}, v.args...), WithEnvironment(env)); rc != v.rc {
+			t.Errorf("Error. Args: %v. Expected: %d. Received: %d.", v.args, v.rc, rc)
+		}
+		if cmd.ran != v.ran || cmd.dryRun != v.dryRun {
+			t.Errorf("Error. Args: %v. Expected: %t %t. Received: %t %t.", v.args, v.ran, v.dryRun, cmd.ran, cmd.dryRun)
+		}
+		if !strings.Contains(logs.String(), v.log) {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %s.", v.args, v.log, logs.String())
+		}
+	}
+}
+
+func TestDestructivePreRun(t *testing.T) {
+	var logs bytes.Buffer
+	dryRun := false
+	cmd := &destructiveTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "purge"}}
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{cmd},
+		PreRun: []Hook{func(ctx context.Context, cmd Subcommand) error {
+			dryRun = DryRun(ctx)
+			return errors.New("not logged in")
+		}},
+	}
+	env := &Environment{Logger: log.New(&logs, "", 0)}
+
+	// A command stopped by a PreRun hook is never confirmed.
+	if rc := Invoke(context.Background(), root, []string{"tool", "purge"}, WithEnvironment(env)); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if logs.String() != "not logged in\n" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "not logged in", logs.String())
+	}
+
+	// The hooks know about -dry-run.
+	Invoke(context.Background(), root, []string{"tool", "purge", "-dry-run"}, WithEnvironment(env))
+	if !dryRun {
+		t.Errorf("Error. Expected the PreRun hook to see the dry run.")
+	}
+}
+
+func TestPrompterConfirm(t *testing.T) {
+	cases := []struct {
+		confirmation Confirmation
+		input        string
+		expected     bool
+		output       string
+	}{
+		{Confirmation{Message: "Purge?"}, "y\n", true, "Purge? [y/N]: "},
+		{Confirmation{Message: "Purge?"}, "YES\n", true, "Purge? [y/N]: "},
+		{Confirmation{Message: "Purge?"}, "\n", false, "Purge? [y/N]: "},
+		{Confirmation{Message: "Purge vol-1?", Typed: "vol-1"}, "vol-1\n", true, "Purge vol-1?\nType vol-1 to confirm: "},
+		{Confirmation{Message: "Purge vol-1?", Typed: "vol-1"}, "y\n", false, "Purge vol-1?\nType vol-1 to confirm: "},
+	}
+	for _, v := range cases {
+		var out bytes.Buffer
+		p := &prompter{in: bufio.NewReader(strings.NewReader(v.input)), out: &out}
+		if ok, err := p.confirm(v.confirmation); ok != v.expected || err != nil {
+			t.Errorf("Error. Input: %q. Expected: %t. Received: %t %v.", v.input, v.expected, ok, err)
+		}
+		if out.String() != v.output {
+			t.Errorf("Error. Expected: %q. Received: %q.", v.output, out.String())
+		}
+	}
+
+	p := &prompter{in: bufio.NewReader(strings.NewReader("")), out: &bytes.Buffer{}}
+	if ok, err := p.confirm(Confirmation{Message: "Purge?"}); ok || err == nil {
+		t.Errorf("Error. Expected: false EOF. Received: %t %v.", ok, err)
+	}
+}
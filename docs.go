@@ -0,0 +1,352 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DocFormat is the format of the reference documentation written
+// by WriteDocs.
+type DocFormat int
+
+const (
+	DocMarkdown DocFormat = iota
+	DocHTML
+)
+
+func (self DocFormat) extension() string {
+	if self == DocHTML {
+		return ".html"
+	}
+	return ".md"
+}
+
+// DocPageName returns the file name of the page of info, which is
+// its path joined by "-", like tool-remote-add.md.
+func DocPageName(info CommandInfo, format DocFormat) string {
+	return strings.Join(info.Path, "-") + format.extension()
+}
+
+// docWriter renders the parts of a page. The pages are built the
+// same way for every format, only the markup differs.
+type docWriter interface {
+	text(text string) string
+	inlineCode(text string) string
+	heading(level int, text string) string
+	paragraph(text string) string
+	code(text string) string
+	link(text string, href string) string
+	// The items and cells are rendered already.
+	list(items []string) string
+	table(head []string, rows [][]string) string
+	page(title string, body []string) string
+}
+
+func newDocWriter(format DocFormat) docWriter {
+	if format == DocHTML {
+		return htmlDoc{}
+	}
+	return markdownDoc{}
+}
+
+// WriteDocPage writes the reference page of the command described
+// by info. parent is the command above it, or nil for the root.
+func WriteDocPage(w io.Writer, info CommandInfo, parent *CommandInfo, format DocFormat) error {
+	d := newDocWriter(format)
+	title := strings.Join(info.Path, " ")
+	body := []string{d.paragraph(info.Description), d.heading(2, "Usage")}
+
+	usage := title
+	if len(info.Flags) > 0 {
+		usage += " [flags]"
+	}
+	if len(info.Children) > 0 {
+		usage += " <command>"
+	}
+	body = append(body, d.code(usage+" [args]"))
+	if info.Default != "" {
+		body = append(body, d.paragraph(fmt.Sprintf("Runs %s when no command is given.", info.Default)))
+	}
+
+	if len(info.Children) > 0 {
+		rows := [][]string{}
+		for _, v := range info.Children {
+			rows = append(rows, []string{d.link(v.Name, DocPageName(v, format)), d.text(v.Description)})
+		}
+		body = append(body, d.heading(2, "Commands"), d.table([]string{"Command", "Description"}, rows))
+	}
+
+	if len(info.Flags) > 0 {
+		rows := [][]string{}
+		for _, v := range info.Flags {
+			text := v.Usage
+			if len(v.Choices) > 0 {
+				text += " One of: " + v.choicesText() + "."
+			}
+			def := ""
+			if v.hasDefault() {
+				def = v.Default
+			}
+			rows = append(rows, []string{d.inlineCode("-" + v.Name), d.text(v.typeText()), d.text(def), d.text(text)})
+		}
+		body = append(body, d.heading(2, "Flags"), d.table([]string{"Flag", "Type", "Default", "Description"}, rows))
+	}
+
+	if len(info.Constraints) > 0 {
+		items := make([]string, len(info.Constraints))
+		for i, v := range info.Constraints {
+			items[i] = d.text(constraintText(v))
+		}
+		body = append(body, d.heading(2, "Constraints"), d.list(items))
+	}
+
+	if len(info.Examples) > 0 {
+		body = append(body, d.heading(2, "Examples"))
+		for _, v := range info.Examples {
+			if v.Description != "" {
+				body = append(body, d.paragraph(v.Description))
+			}
+			body = append(body, d.code(v.Command))
+		}
+	}
+
+	seeAlso := []string{}
+	if parent != nil {
+		seeAlso = append(seeAlso, d.link(strings.Join(parent.Path, " "), DocPageName(*parent, format)))
+	}
+	for _, v := range info.Children {
+		seeAlso = append(seeAlso, d.link(strings.Join(v.Path, " "), DocPageName(v, format)))
+	}
+	if len(seeAlso) > 0 {
+		body = append(body, d.heading(2, "See also"), d.list(seeAlso))
+	}
+
+	_, err := io.WriteString(w, d.page(title, body))
+	return err
+}
+
+// WriteDocIndex writes the index of every command below and
+// including info, sorted by path.
+func WriteDocIndex(w io.Writer, info CommandInfo, format DocFormat) error {
+	d := newDocWriter(format)
+	all := []CommandInfo{}
+	info.Walk(func(v CommandInfo) {
+		all = append(all, v)
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return strings.Join(all[i].Path, " ") < strings.Join(all[j].Path, " ")
+	})
+
+	rows := [][]string{}
+	for _, v := range all {
+		rows = append(rows, []string{d.link(strings.Join(v.Path, " "), DocPageName(v, format)), d.text(v.Description)})
+	}
+	title := info.Name + " reference"
+	_, err := io.WriteString(w, d.page(title, []string{d.table([]string{"Command", "Description"}, rows)}))
+	return err
+}
+
+// WriteDocs writes the index and a page for root and every
+// command below it into dir, and returns the paths of the files.
+func WriteDocs(dir string, root Subcommand, format DocFormat) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	info := Describe(root)
+
+	index := filepath.Join(dir, "index"+format.extension())
+	if err := writeFile(index, func(w io.Writer) error {
+		return WriteDocIndex(w, info, format)
+	}); err != nil {
+		return nil, err
+	}
+	return writeDocPages(dir, info, nil, format, []string{index})
+}
+
+func writeDocPages(dir string, info CommandInfo, parent *CommandInfo, format DocFormat, written []string) ([]string, error) {
+	path := filepath.Join(dir, DocPageName(info, format))
+	if err := writeFile(path, func(w io.Writer) error {
+		return WriteDocPage(w, info, parent, format)
+	}); err != nil {
+		return written, err
+	}
+
+	written = append(written, path)
+	for _, v := range info.Children {
+		var err error
+		if written, err = writeDocPages(dir, v, &info, format, written); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// writeFile creates path and writes it with write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+type markdownDoc struct{}
+
+// markdownEscape keeps text from being read as markup, and a
+// table cell on one line.
+func markdownEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "[", `\[`, "]", `\]`).Replace(s)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func (markdownDoc) text(text string) string {
+	return markdownEscape(text)
+}
+
+func (markdownDoc) inlineCode(text string) string {
+	return "`" + strings.ReplaceAll(text, "`", "") + "`"
+}
+
+func (markdownDoc) heading(level int, text string) string {
+	return strings.Repeat("#", level) + " " + markdownEscape(text)
+}
+
+func (markdownDoc) paragraph(text string) string {
+	return markdownEscape(text)
+}
+
+func (markdownDoc) code(text string) string {
+	return "```\n" + text + "\n```"
+}
+
+func (markdownDoc) link(text string, href string) string {
+	return "[" + markdownEscape(text) + "](" + href + ")"
+}
+
+func (markdownDoc) list(items []string) string {
+	lines := make([]string, len(items))
+	for i, v := range items {
+		lines[i] = "- " + v
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (markdownDoc) table(head []string, rows [][]string) string {
+	lines := []string{
+		"| " + strings.Join(head, " | ") + " |",
+		"|" + strings.Repeat(" --- |", len(head)),
+	}
+	for _, row := range rows {
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (self markdownDoc) page(title string, body []string) string {
+	return self.heading(1, title) + "\n\n" + strings.Join(body, "\n\n") + "\n"
+}
+
+type htmlDoc struct{}
+
+func (htmlDoc) text(text string) string {
+	return html.EscapeString(text)
+}
+
+func (htmlDoc) inlineCode(text string) string {
+	return "<code>" + html.EscapeString(text) + "</code>"
+}
+
+func (htmlDoc) heading(level int, text string) string {
+	return fmt.Sprintf("<h%d>%s</h%d>", level, html.EscapeString(text), level)
+}
+
+func (htmlDoc) paragraph(text string) string {
+	return "<p>" + html.EscapeString(text) + "</p>"
+}
+
+func (htmlDoc) code(text string) string {
+	return "<pre><code>" + html.EscapeString(text) + "</code></pre>"
+}
+
+func (htmlDoc) link(text string, href string) string {
+	return `<a href="` + html.EscapeString(href) + `">` + html.EscapeString(text) + "</a>"
+}
+
+func (htmlDoc) list(items []string) string {
+	lines := []string{"<ul>"}
+	for _, v := range items {
+		lines = append(lines, "<li>"+v+"</li>")
+	}
+	return strings.Join(append(lines, "</ul>"), "\n")
+}
+
+func (htmlDoc) table(head []string, rows [][]string) string {
+	lines := []string{"<table>", "<tr><th>" + strings.Join(head, "</th><th>") + "</th></tr>"}
+	for _, row := range rows {
+		lines = append(lines, "<tr><td>"+strings.Join(row, "</td><td>")+"</td></tr>")
+	}
+	return strings.Join(append(lines, "</table>"), "\n")
+}
+
+func (self htmlDoc) page(title string, body []string) string {
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + html.EscapeString(title) +
+		"</title>\n</head>\n<body>\n" + self.heading(1, title) + "\n" + strings.Join(body, "\n") + "\n</body>\n</html>\n"
+}
+
+// DocsCommand is a Subcommand which writes the reference
+// documentation of Root into the directory given with -dir.
+type DocsCommand struct {
+	flagSet *flag.FlagSet
+	Name    string
+	Root    Subcommand
+	dir     string
+	html    bool
+}
+
+func (self *DocsCommand) Description() string {
+	return "Write the reference documentation."
+}
+
+func (self *DocsCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *DocsCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.StringVar(&self.dir, "dir", ".", "The directory to write the pages into.")
+	self.flagSet.BoolVar(&self.html, "html", false, "Write HTML instead of Markdown.")
+	return self
+}
+
+func (self *DocsCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *DocsCommand) Execute(ctx context.Context) int {
+	format := DocMarkdown
+	if self.html {
+		format = DocHTML
+	}
+	env := Env(ctx)
+	paths, err := WriteDocs(self.dir, self.Root, format)
+	if err != nil {
+		env.logf(slog.LevelError, "Unable to write the documentation. %s", err)
+		return 1
+	}
+	for _, v := range paths {
+		fmt.Fprintln(env.Stdout, v)
+	}
+	return 0
+}
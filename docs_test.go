@@ -0,0 +1,88 @@
+package glarg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type docsTestCommand struct {
+	introspectTestCommand
+}
+
+func (self *docsTestCommand) SetupSubcommand() Subcommand {
+	self.introspectTestCommand.SetupSubcommand()
+	return self
+}
+
+func (self *docsTestCommand) Examples() []Example {
+	return []Example{{Command: "tool get -id 6f1c...", Description: "Get one <thing>."}}
+}
+
+func TestWriteDocs(t *testing.T) {
+	newRoot := func() *Subcommands {
+		root := &Subcommands{
+			Name: "tool",
+			Children: []Subcommand{
+				&Subcommands{Name: "remote", Children: []Subcommand{&SubcommandNoOp{Name: "add"}}},
+				&docsTestCommand{introspectTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}},
+			},
+		}
+		root.SetupSubcommand()
+		return root
+	}
+
+	dir := t.TempDir()
+	paths, err := WriteDocs(dir, newRoot(), DocMarkdown)
+	if err != nil {
+		t.Fatalf("Error. %s", err)
+	}
+	names := []string{}
+	for _, v := range paths {
+		names = append(names, filepath.Base(v))
+	}
+	if strings.Join(names, " ") != "index.md tool.md tool-remote.md tool-remote-add.md tool-get.md" {
+		t.Errorf("Error. Expected the pages. Received: %v.", names)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "index.md"))
+	expected := "# tool reference\n\n| Command | Description |\n| --- | --- |\n" +
+		"| [tool](tool.md) | Subcommands: remote, get |\n" +
+		"| [tool get](tool-get.md) | Not actually implemented. |\n" +
+		"| [tool remote](tool-remote.md) | Subcommands: add |\n" +
+		"| [tool remote add](tool-remote-add.md) | Not actually implemented. |\n"
+	if string(content) != expected {
+		t.Errorf("Error. Expected: %s. Received: %s.", expected, content)
+	}
+
+	content, _ = os.ReadFile(filepath.Join(dir, "tool-get.md"))
+	for _, v := range []string{
+		"# tool get\n\nNot actually implemented.\n\n## Usage\n\n```\ntool get [flags] [args]\n```",
+		"| `-format` | EnumFlag | text | The format. One of: text, json. |",
+		"| `-ids` | SliceFlag of uuid.UUID, separated by \";\" |  | The ids. |",
+		"| `-password` | string | \\[REDACTED\\] | The password. |",
+		"## Constraints\n\n- At most one of: -id, -ids.",
+		"## Examples\n\nGet one &lt;thing>.\n\n```\ntool get -id 6f1c...\n```",
+		"## See also\n\n- [tool](tool.md)\n",
+	} {
+		if !strings.Contains(string(content), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, content)
+		}
+	}
+
+	if _, err := WriteDocs(dir, newRoot(), DocHTML); err != nil {
+		t.Fatalf("Error. %s", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "tool-get.html"))
+	for _, v := range []string{
+		"<title>tool get</title>",
+		"<tr><td><code>-format</code></td><td>EnumFlag</td><td>text</td><td>The format. One of: text, json.</td></tr>",
+		"<p>Get one &lt;thing&gt;.</p>\n<pre><code>tool get -id 6f1c...</code></pre>",
+		"<ul>\n<li><a href=\"tool.html\">tool</a></li>\n</ul>",
+	} {
+		if !strings.Contains(string(content), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, content)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package glarg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnumChoice is one of the allowed values of an EnumFlag
+// along with an optional description shown in the help.
+type EnumChoice struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+}
+
+// enumChoices holds the logic shared between the EnumFlag
+// and the EnumSliceFlagTarget.
+type enumChoices struct {
+	choices    []EnumChoice
+	ignoreCase bool
+}
+
+func newEnumChoices(values []string) enumChoices {
+	choices := make([]EnumChoice, len(values))
+	for i, v := range values {
+		choices[i] = EnumChoice{Value: v}
+	}
+	return enumChoices{choices: choices}
+}
+
+func (self enumChoices) values() []string {
+	values := make([]string, len(self.choices))
+	for i, v := range self.choices {
+		values[i] = v.Value
+	}
+	return values
+}
+
+// match returns the declared value for s, so a case insensitive
+// match still stores the value the way it was declared.
+func (self enumChoices) match(s string) (string, error) {
+	for _, v := range self.choices {
+		if v.Value == s || (self.ignoreCase && strings.EqualFold(v.Value, s)) {
+			return v.Value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid value %q, must be one of: %s", s, strings.Join(self.values(), ", "))
+}
+
+func (self enumChoices) usage(text string) string {
+	hasDescriptions := false
+	for _, v := range self.choices {
+		if v.Description != "" {
+			hasDescriptions = true
+			break
+		}
+	}
+
+	if !hasDescriptions {
+		return fmt.Sprintf("%s (one of: %s)", text, strings.Join(self.values(), ", "))
+	}
+
+	width := 0
+	for _, v := range self.choices {
+		if len(v.Value) > width {
+			width = len(v.Value)
+		}
+	}
+	lines := []string{text + ". One of:"}
+	for _, v := range self.choices {
+		lines = append(lines, fmt.Sprintf("  %-*s  %s", width, v.Value, v.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (self enumChoices) complete(prefix string) []string {
+	matches := []string{}
+	for _, v := range self.choices {
+		if strings.HasPrefix(v.Value, prefix) ||
+			(self.ignoreCase && strings.HasPrefix(strings.ToLower(v.Value), strings.ToLower(prefix))) {
+			matches = append(matches, v.Value)
+		}
+	}
+	return matches
+}
+
+// Enum flag getter. Restricts a string flag to a declared
+// set of values. This type implements the flag.Getter and
+// the Completer interfaces.
+type EnumFlag struct {
+	enumChoices
+	ptr *string
+}
+
+func NewEnumFlag(v *string, values ...string) *EnumFlag {
+	return &EnumFlag{
+		enumChoices: newEnumChoices(values),
+		ptr:         v,
+	}
+}
+
+func NewEnumFlagWithChoices(v *string, choices []EnumChoice) *EnumFlag {
+	return &EnumFlag{
+		enumChoices: enumChoices{choices: choices},
+		ptr:         v,
+	}
+}
+
+// IgnoreCase makes the flag accept values regardless of
+// their case. The value stored is always the declared one.
+func (self *EnumFlag) IgnoreCase() *EnumFlag {
+	self.ignoreCase = true
+	return self
+}
+
+// Choices returns the allowed values in declaration order.
+func (self EnumFlag) Choices() []EnumChoice {
+	return self.choices
+}
+
+// Usage decorates the usage text with the allowed values so
+// they show up in the FlagSet's PrintDefaults output.
+func (self EnumFlag) Usage(text string) string {
+	return self.usage(text)
+}
+
+func (self EnumFlag) String() string {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *EnumFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = new(string)
+	}
+
+	if v, err := self.match(s); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self EnumFlag) Get() interface{} {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *EnumFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = ""
+	}
+}
+
+func (self EnumFlag) Complete(prefix string) []string {
+	return self.complete(prefix)
+}
+
+// EnumSliceFlagTarget is an Enum Target for a
+// SliceFlag.
+type EnumSliceFlagTarget struct {
+	enumChoices
+	Target *[]string
+}
+
+func NewEnumSliceFlagTarget(v *[]string, values ...string) *EnumSliceFlagTarget {
+	return &EnumSliceFlagTarget{
+		enumChoices: newEnumChoices(values),
+		Target:      v,
+	}
+}
+
+func NewEnumSliceFlagTargetWithChoices(v *[]string, choices []EnumChoice) *EnumSliceFlagTarget {
+	return &EnumSliceFlagTarget{
+		enumChoices: enumChoices{choices: choices},
+		Target:      v,
+	}
+}
+
+// IgnoreCase makes the target accept values regardless of
+// their case. The values stored are always the declared ones.
+func (self *EnumSliceFlagTarget) IgnoreCase() *EnumSliceFlagTarget {
+	self.ignoreCase = true
+	return self
+}
+
+func (self *EnumSliceFlagTarget) Choices() []EnumChoice {
+	return self.choices
+}
+
+func (self *EnumSliceFlagTarget) Usage(text string) string {
+	return self.usage(text)
+}
+
+func (self *EnumSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]string{}
+	}
+}
+
+func (self *EnumSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *EnumSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	if v, err := self.match(item); err != nil {
+		return nil, err
+	} else {
+		*self.Target = append(*self.Target, v)
+	}
+	return self, nil
+}
+
+func (self *EnumSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	return strings.Join(*self.Target, del)
+}
+
+func (self *EnumSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
+
+func (self *EnumSliceFlagTarget) Complete(prefix string) []string {
+	return self.complete(prefix)
+}
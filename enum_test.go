@@ -0,0 +1,106 @@
+package glarg
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestEnumFlag(t *testing.T) {
+	output := "table"
+	flag1 := NewEnumFlag(&output, "json", "yaml", "table")
+
+	if flag1.String() != "table" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "table", flag1.String())
+	}
+
+	if err := flag1.Set("json"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if output != "json" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "json", output)
+	}
+
+	// The error lists the valid choices.
+	if err := flag1.Set("JSON"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	} else if !strings.Contains(err.Error(), "json, yaml, table") {
+		t.Errorf("Error. Expected the choices in the error. Received: %s", err)
+	}
+
+	// Case insensitive stores the declared value.
+	flag1.IgnoreCase()
+	if err := flag1.Set("YAML"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if tmp, ok := flag1.Get().(string); !ok {
+		t.Errorf("Error. Expected a string. Got something else.")
+	} else if tmp != "yaml" {
+		t.Errorf("Error. Expected: %s. Received: %s", "yaml", tmp)
+	}
+
+	// A default EnumFlag is empty.
+	flag2 := &EnumFlag{}
+	if flag2.String() != "" {
+		t.Errorf("Error. Expected an empty string. Received: %s.", flag2.String())
+	}
+	if err := flag2.Set("anything"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	fs := flag.NewFlagSet("EnumFlag", flag.ContinueOnError)
+	fs.Var(flag1, "output", flag1.Usage("output format"))
+	if err := fs.Parse([]string{"-output", "table"}); err != nil {
+		t.Errorf("Error. Expected parse to work. Received: %s", err)
+	}
+	if output != "table" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "table", output)
+	}
+	if usage := fs.Lookup("output").Usage; usage != "output format (one of: json, yaml, table)" {
+		t.Errorf("Error. Unexpected usage. Received: %s", usage)
+	}
+}
+
+func TestEnumFlagWithChoices(t *testing.T) {
+	var level string
+	flag1 := NewEnumFlagWithChoices(&level, []EnumChoice{
+		{Value: "debug", Description: "Everything."},
+		{Value: "error", Description: "Only errors."},
+	})
+
+	usage := flag1.Usage("log level")
+	expected := "log level. One of:\n  debug  Everything.\n  error  Only errors."
+	if usage != expected {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, usage)
+	}
+
+	if matches := flag1.Complete("d"); len(matches) != 1 || matches[0] != "debug" {
+		t.Errorf("Error. Expected: [debug]. Received: %v.", matches)
+	}
+	if matches := flag1.Complete(""); len(matches) != 2 {
+		t.Errorf("Error. Expected 2 matches. Received: %v.", matches)
+	}
+}
+
+func TestEnumSliceFlagTarget(t *testing.T) {
+	var columns []string
+	target := NewEnumSliceFlagTarget(&columns, "id", "name", "created").IgnoreCase()
+	flag1 := NewSliceFlag(target, "")
+
+	if err := flag1.Set("id,NAME"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if flag1.String() != "id,name" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "id,name", flag1.String())
+	}
+
+	if err := flag1.Set("id,size"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	// Completion works on the last element of the list.
+	flag2 := NewSliceFlag(NewEnumSliceFlagTarget(&columns, "id", "name", "created"), "")
+	if matches := flag2.Complete("id,c"); len(matches) != 1 || matches[0] != "id,created" {
+		t.Errorf("Error. Expected: [id,created]. Received: %v.", matches)
+	}
+}
@@ -0,0 +1,193 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+)
+
+// SignalNotifier is where Invoke, the shell and plugins get their
+// signals from. The default relays the process' signals with the
+// os/signal package.
+type SignalNotifier interface {
+	Notify(c chan<- os.Signal, sig ...os.Signal)
+	Stop(c chan<- os.Signal)
+}
+
+type osSignals struct{}
+
+func (osSignals) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	signal.Notify(c, sig...)
+}
+
+func (osSignals) Stop(c chan<- os.Signal) {
+	signal.Stop(c)
+}
+
+// Environment is what the commands use to talk to the outside
+// world. Pass one to Invoke with WithEnvironment to redirect the
+// output of glarg and of the commands using Env, for example to
+// run tests in parallel. Fields left nil use the process' own.
+type Environment struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Used for the usage and the errors glarg reports.
+	Logger *log.Logger
+	// Set by a LogConfig. glarg reports its errors here instead
+	// of to the Logger when it is set.
+	Slog      *slog.Logger
+	LookupEnv func(key string) (string, bool)
+	// The names of the variables passed to plugins, as key=value
+	// pairs like os.Environ. Their values come from LookupEnv.
+	Environ func() []string
+	Signals SignalNotifier
+	// COLOR_AUTO, COLOR_ALWAYS or COLOR_NEVER, see Styler. Empty
+	// is COLOR_AUTO.
+	Color string
+	// Where the -color flags of the tree are stored. Set by
+	// useEnvironment for one run.
+	colorFlags []*string
+}
+
+// DefaultEnvironment returns the Environment of the process. The
+// Logger is the log package's standard logger.
+func DefaultEnvironment() *Environment {
+	return &Environment{
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+		Logger:    log.Default(),
+		LookupEnv: os.LookupEnv,
+		Environ:   os.Environ,
+		Signals:   osSignals{},
+	}
+}
+
+// withDefaults returns a copy of self with the nil fields set
+// from the DefaultEnvironment.
+func (self *Environment) withDefaults() *Environment {
+	env := DefaultEnvironment()
+	if self == nil {
+		return env
+	}
+	if self.Stdin != nil {
+		env.Stdin = self.Stdin
+	}
+	if self.Stdout != nil {
+		env.Stdout = self.Stdout
+	}
+	if self.Stderr != nil {
+		env.Stderr = self.Stderr
+	}
+	if self.Logger != nil {
+		env.Logger = self.Logger
+	}
+	env.Slog = self.Slog
+	if self.LookupEnv != nil {
+		env.LookupEnv = self.LookupEnv
+	}
+	if self.Environ != nil {
+		env.Environ = self.Environ
+	}
+	if self.Signals != nil {
+		env.Signals = self.Signals
+	}
+	env.Color = self.Color
+	return env
+}
+
+// Getenv returns the value of the environment variable key, or ""
+// when it is not set.
+func (self *Environment) Getenv(key string) string {
+	v, _ := self.LookupEnv(key)
+	return v
+}
+
+// homeDir is os.UserHomeDir for the variables of the Environment.
+func (self *Environment) homeDir() (string, error) {
+	key := "HOME"
+	if runtime.GOOS == "windows" {
+		key = "USERPROFILE"
+	}
+	if v := self.Getenv(key); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s is not defined", key)
+}
+
+// environ returns the variables listed by Environ with their
+// values from LookupEnv, for the programs the commands run.
+func (self *Environment) environ() []string {
+	vars := []string{}
+	seen := map[string]bool{}
+	for _, v := range self.Environ() {
+		key, _, _ := strings.Cut(v, "=")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if value, ok := self.LookupEnv(key); ok && key != "" {
+			vars = append(vars, key+"="+value)
+		}
+	}
+	return vars
+}
+
+type environmentKey struct{}
+
+// WithEnvironment makes Invoke run the commands in env.
+func WithEnvironment(env *Environment) InvokeOption {
+	return func(self *invocation) {
+		self.env = env
+	}
+}
+
+// Env returns the Environment the command is run in. Outside of
+// Invoke it is the DefaultEnvironment.
+func Env(ctx context.Context) *Environment {
+	if env, ok := ctx.Value(environmentKey{}).(*Environment); ok {
+		return env
+	}
+	return DefaultEnvironment()
+}
+
+// environmentUser is implemented by flag values which read from
+// the outside world, like the SecretFlag reading stdin.
+type environmentUser interface {
+	useEnvironment(env *Environment)
+}
+
+// useEnvironment points the tree at env. FlagSets writing to the
+// default stderr write to env.Stderr instead, and style their
+// usage for it.
+func useEnvironment(root Subcommand, env *Environment) {
+	walkCommands(root, nil, func(path []string, cmd Subcommand) {
+		if sc, ok := cmd.(*Subcommands); ok {
+			sc.env = env
+			if sc.color != nil {
+				env.colorFlags = append(env.colorFlags, sc.color)
+			}
+		}
+		fs := cmd.FlagSet()
+		if fs == nil {
+			return
+		}
+		if fs.Output() == os.Stderr {
+			fs.SetOutput(env.Stderr)
+		}
+		styleUsage(fs, env)
+		fs.VisitAll(func(f *flag.Flag) {
+			if u, ok := f.Value.(environmentUser); ok {
+				u.useEnvironment(env)
+			}
+		})
+	})
+}
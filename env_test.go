@@ -0,0 +1,119 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type envTestSignals struct {
+	mu       sync.Mutex
+	channels []chan<- os.Signal
+}
+
+func (self *envTestSignals) Notify(c chan<- os.Signal, sig ...os.Signal) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.channels = append(self.channels, c)
+}
+
+func (self *envTestSignals) Stop(c chan<- os.Signal) {}
+
+func (self *envTestSignals) send(sig os.Signal) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for _, v := range self.channels {
+		select {
+		case v <- sig:
+		default:
+		}
+	}
+}
+
+type envTestCommand struct {
+	SubcommandNoOp
+	signals *envTestSignals
+	secret  Secret
+}
+
+func (self *envTestCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	self.flagSet.Var(NewSecretFlag(&self.secret), "secret", "secret")
+	return self
+}
+
+func (self *envTestCommand) Execute(ctx context.Context) int {
+	env := Env(ctx)
+	fmt.Fprintf(env.Stdout, "%s %s", self.secret.Reveal(), env.Getenv("NAME"))
+	if self.signals == nil {
+		return 0
+	}
+	// handleInterupt may not be listening yet.
+	for i := 0; i < 100; i++ {
+		self.signals.send(os.Interrupt)
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return 1
+}
+
+func TestWithEnvironment(t *testing.T) {
+	var stdout, stderr, logs bytes.Buffer
+	signals := &envTestSignals{}
+	env := &Environment{
+		Stdin:  strings.NewReader("from-stdin\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Logger: log.New(&logs, "", 0),
+		LookupEnv: func(key string) (string, bool) {
+			if key == "NAME" {
+				return "from-env", true
+			}
+			return "", false
+		},
+		Signals: signals,
+	}
+	cmd := &envTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "run"}, signals: signals}
+	root := &Subcommands{Name: "root", Children: []Subcommand{
+		&Subcommands{Name: "nested", Children: []Subcommand{cmd}},
+	}}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "nested", "run", "-secret", "-"}, WithEnvironment(env))
+	if rc != 0 {
+		t.Errorf("Error. Expected the interrupt to cancel the context. Received: %d.", rc)
+	}
+	if stdout.String() != "from-stdin from-env" {
+		t.Errorf("Error. Expected: from-stdin from-env. Received: %s.", stdout.String())
+	}
+
+	cmd.signals = nil
+	Invoke(context.Background(), root, []string{"tool", "nested", "missing"}, WithEnvironment(env))
+	if !strings.Contains(logs.String(), "Unknown subcommand provided: missing.\n  run ") {
+		t.Errorf("Error. Expected the usage in the logger. Received: %s.", logs.String())
+	}
+
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "run", "-bad"}, WithEnvironment(env), ContinueOnError())
+	if rc != 2 || !strings.Contains(stderr.String(), "flag provided but not defined: -bad") {
+		t.Errorf("Error. Expected the flag error in stderr. Received: %d %s.", rc, stderr.String())
+	}
+}
+
+func TestEnvDefault(t *testing.T) {
+	env := Env(context.Background())
+	if env.Stdout != os.Stdout || env.Logger != log.Default() {
+		t.Errorf("Error. Expected the default environment.")
+	}
+	t.Setenv("GLARG_ENV_TEST", "x")
+	if env.Getenv("GLARG_ENV_TEST") != "x" {
+		t.Errorf("Error. Expected: x. Received: %s.", env.Getenv("GLARG_ENV_TEST"))
+	}
+}
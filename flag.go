@@ -1,6 +1,7 @@
 package glarg
 
 import (
+	"flag"
 	"net/url"
 	"strings"
 
@@ -22,6 +23,46 @@ type SliceFlagTarget interface {
 	Get() interface{}
 }
 
+// Validator is implemented by flag values which can only be
+// checked once all of the flags are parsed, like the PathFlag.
+type Validator interface {
+	Validate() error
+}
+
+// ValidateFlags validates every flag explicitly set in fs whose
+// value is a Validator, and the defaults of the flags left out
+// whose value is a defaultValidator. Subcommands calls this before
+// UnpackArgs.
+func ValidateFlags(fs *flag.FlagSet) []FieldError {
+	errs := []FieldError{}
+	if fs == nil {
+		return errs
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	fs.VisitAll(func(f *flag.Flag) {
+		var err error
+		if v, ok := f.Value.(Validator); ok && set[f.Name] {
+			err = v.Validate()
+		} else if v, ok := f.Value.(defaultValidator); ok && !set[f.Name] {
+			err = v.validateDefault()
+		}
+		if err != nil {
+			errs = append(errs, FieldError{Flag: f.Name, Value: f.Value.String(), Message: err.Error()})
+		}
+	})
+	return errs
+}
+
+// defaultValidator is a flag value which also prepares and checks
+// its default, when the flag is not given, like the PathFlag
+// expanding ~ in a default path.
+type defaultValidator interface {
+	validateDefault() error
+}
+
 // Deal with getting multiple string values on the command line.
 // By default it slices on comma, but you can change that
 // during the subcommand setup.
@@ -73,6 +114,38 @@ func (self *SliceFlag) Set(s string) error {
 	return nil
 }
 
+// Complete completes the last element of the list when the
+// target is a Completer.
+func (self SliceFlag) Complete(prefix string) []string {
+	c, ok := self.target.(Completer)
+	if !ok {
+		return []string{}
+	}
+
+	del := self.delimiter
+	if del == "" {
+		del = DEFAULT_DELIMITER
+	}
+	head := ""
+	if i := strings.LastIndex(prefix, del); i >= 0 {
+		head, prefix = prefix[:i+len(del)], prefix[i+len(del):]
+	}
+
+	matches := c.Complete(prefix)
+	for i, v := range matches {
+		matches[i] = head + v
+	}
+	return matches
+}
+
+// Validate validates the target when it is a Validator.
+func (self SliceFlag) Validate() error {
+	if v, ok := self.target.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
 func (self SliceFlag) Get() interface{} {
 	if self.target == nil {
 		self.target = &StringSliceFlagTarget{&[]string{}}
@@ -80,6 +153,21 @@ func (self SliceFlag) Get() interface{} {
 	return self.target.Get()
 }
 
+// Reset empties the target. See Resetter.
+func (self *SliceFlag) Reset() {
+	if self.target != nil {
+		self.target.Clear()
+	}
+}
+
+// useEnvironment passes env on to the target, like the
+// PathSliceFlagTarget expanding variables.
+func (self *SliceFlag) useEnvironment(env *Environment) {
+	if u, ok := self.target.(environmentUser); ok {
+		u.useEnvironment(env)
+	}
+}
+
 // StringSliceFlagTarget is a String Target for a
 // SliceFlag.
 type StringSliceFlagTarget struct {
@@ -195,6 +283,12 @@ func (self UUIDFlag) Get() interface{} {
 	}
 }
 
+func (self *UUIDFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = uuid.Nil
+	}
+}
+
 // URLSliceFlagTarget is a URL Target for a
 // SliceFlag.
 type URLSliceFlagTarget struct {
@@ -276,3 +370,9 @@ func (self URLFlag) Get() interface{} {
 		return self.ptr
 	}
 }
+
+func (self *URLFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = url.URL{}
+	}
+}
@@ -0,0 +1,229 @@
+// Package glargtest runs glarg command trees in the test process
+// and captures what they write, so commands can be tested without
+// building and running the program.
+//
+// Run gives the commands their own glarg.Environment, so tests can
+// run in parallel as long as the commands use glarg.Env instead of
+// os.Stdout, os.Getenv and the log package.
+package glargtest
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hautenessa/glarg"
+)
+
+// UPDATE_GOLDEN_ENV is the environment variable which makes
+// AssertGolden write the golden files instead of comparing them.
+const UPDATE_GOLDEN_ENV = "GLARG_UPDATE_GOLDEN"
+
+// Result is what a command did.
+type Result struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	// The output of the Environment's Logger, without timestamps.
+	Log string
+}
+
+// Option changes how Run runs the command.
+type Option func(*config)
+
+type config struct {
+	prog  string
+	stdin string
+	env   map[string]string
+	clock *Clock
+	opts  []glarg.InvokeOption
+}
+
+// WithProg sets the program name passed to Invoke as args[0].
+// Defaults to "test".
+func WithProg(prog string) Option {
+	return func(self *config) {
+		self.prog = prog
+	}
+}
+
+// WithStdin gives the command s as its standard input.
+func WithStdin(s string) Option {
+	return func(self *config) {
+		self.stdin = s
+	}
+}
+
+// WithEnv sets an environment variable for glarg.Env. The
+// process' environment is not changed.
+func WithEnv(name string, value string) Option {
+	return func(self *config) {
+		self.env[name] = value
+	}
+}
+
+// WithClock makes glarg.Now return the time of clock.
+func WithClock(clock *Clock) Option {
+	return func(self *config) {
+		self.clock = clock
+	}
+}
+
+// WithInvokeOptions passes opts on to Invoke.
+func WithInvokeOptions(opts ...glarg.InvokeOption) Option {
+	return func(self *config) {
+		self.opts = append(self.opts, opts...)
+	}
+}
+
+// Run invokes root with args, which do not include the program
+// name, and returns what it did. Every FlagSet is switched to
+// flag.ContinueOnError, so bad flags return exit code 2 instead
+// of exiting the test.
+func Run(t testing.TB, root glarg.Subcommand, args []string, opts ...Option) *Result {
+	t.Helper()
+	cfg := &config{prog: "test", env: map[string]string{}}
+	for _, v := range opts {
+		v(cfg)
+	}
+
+	var stdout, stderr, logs bytes.Buffer
+	env := &glarg.Environment{
+		Stdin:  strings.NewReader(cfg.stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+		Logger: log.New(&logs, "", 0),
+		LookupEnv: func(key string) (string, bool) {
+			if v, ok := cfg.env[key]; ok {
+				return v, true
+			}
+			return os.LookupEnv(key)
+		},
+		Environ: func() []string {
+			vars := os.Environ()
+			for k, v := range cfg.env {
+				vars = append(vars, k+"="+v)
+			}
+			return vars
+		},
+	}
+
+	invokeOpts := append([]glarg.InvokeOption{glarg.ContinueOnError(), glarg.WithEnvironment(env)}, cfg.opts...)
+	if cfg.clock != nil {
+		invokeOpts = append(invokeOpts, glarg.WithClock(cfg.clock.Now))
+	}
+
+	rc := glarg.Invoke(context.Background(), root, append([]string{cfg.prog}, args...), invokeOpts...)
+	return &Result{
+		ExitCode: rc,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Log:      logs.String(),
+	}
+}
+
+// Clock is a fake clock for WithClock. It only moves when told to.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (self *Clock) Now() time.Time {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.now
+}
+
+// Advance moves the clock forward by d.
+func (self *Clock) Advance(d time.Duration) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.now = self.now.Add(d)
+}
+
+// AssertExitCode fails t when the command did not exit with rc.
+func (self *Result) AssertExitCode(t testing.TB, rc int) {
+	t.Helper()
+	if self.ExitCode != rc {
+		t.Errorf("Error. Expected exit code: %d. Received: %d.\n%s", rc, self.ExitCode, self.output())
+	}
+}
+
+// AssertStdout fails t when the standard output is not expected.
+func (self *Result) AssertStdout(t testing.TB, expected string) {
+	t.Helper()
+	if self.Stdout != expected {
+		t.Errorf("Error. Expected stdout: %q. Received: %q.", expected, self.Stdout)
+	}
+}
+
+// AssertStdoutContains fails t when the standard output does not
+// contain s.
+func (self *Result) AssertStdoutContains(t testing.TB, s string) {
+	t.Helper()
+	if !strings.Contains(self.Stdout, s) {
+		t.Errorf("Error. Expected stdout to contain: %q. Received: %q.", s, self.Stdout)
+	}
+}
+
+// AssertStderrContains fails t when the standard error does not
+// contain s.
+func (self *Result) AssertStderrContains(t testing.TB, s string) {
+	t.Helper()
+	if !strings.Contains(self.Stderr, s) {
+		t.Errorf("Error. Expected stderr to contain: %q. Received: %q.", s, self.Stderr)
+	}
+}
+
+// AssertLogContains fails t when the log does not contain s.
+func (self *Result) AssertLogContains(t testing.TB, s string) {
+	t.Helper()
+	if !strings.Contains(self.Log, s) {
+		t.Errorf("Error. Expected the log to contain: %q. Received: %q.", s, self.Log)
+	}
+}
+
+// Output returns the standard error followed by the log, which is
+// where glarg writes the usage and the help.
+func (self *Result) Output() string {
+	return self.Stderr + self.Log
+}
+
+func (self *Result) output() string {
+	return "stdout:\n" + self.Stdout + "\nstderr:\n" + self.Stderr + "\nlog:\n" + self.Log
+}
+
+// AssertGolden compares actual with the file testdata/<name>.golden,
+// and fails t when they differ. The file is written instead when
+// UPDATE_GOLDEN_ENV is set.
+func AssertGolden(t testing.TB, name string, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UPDATE_GOLDEN_ENV) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Unable to create the golden file. %s", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatalf("Unable to write the golden file. %s", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read the golden file, set %s=1 to write it. %s", UPDATE_GOLDEN_ENV, err)
+	}
+	if string(expected) != actual {
+		t.Errorf("Error. %s differs from the golden file.\nExpected:\n%s\nReceived:\n%s", name, expected, actual)
+	}
+}
@@ -0,0 +1,76 @@
+package glargtest
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hautenessa/glarg"
+)
+
+type echoCommand struct {
+	flagSet *flag.FlagSet
+	upper   bool
+}
+
+func (self *echoCommand) Description() string {
+	return "Echo stdin."
+}
+
+func (self *echoCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *echoCommand) SetupSubcommand() glarg.Subcommand {
+	self.flagSet = flag.NewFlagSet("echo", flag.ExitOnError)
+	self.flagSet.BoolVar(&self.upper, "upper", false, "Shout.")
+	return self
+}
+
+func (self *echoCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *echoCommand) Execute(ctx context.Context) int {
+	env := glarg.Env(ctx)
+	in, _ := io.ReadAll(env.Stdin)
+	fmt.Fprintf(env.Stdout, "%s %s %s", in, env.Getenv("ECHO_NAME"), glarg.Now(ctx).Format(time.RFC3339))
+	fmt.Fprintln(env.Stderr, "done")
+	return 0
+}
+
+func newTree() glarg.Subcommand {
+	return &glarg.Subcommands{Name: "tool", Children: []glarg.Subcommand{&echoCommand{}}}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	clock := NewClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	clock.Advance(time.Hour)
+
+	result := Run(t, newTree(), []string{"echo"},
+		WithStdin("hello"), WithEnv("ECHO_NAME", "world"), WithClock(clock))
+	result.AssertExitCode(t, 0)
+	result.AssertStdout(t, "hello world 2024-03-01T13:00:00Z")
+	result.AssertStdoutContains(t, "world")
+	result.AssertStderrContains(t, "done")
+
+	// Bad flags and unknown commands return instead of exiting.
+	result = Run(t, newTree(), []string{"echo", "-bad"})
+	result.AssertExitCode(t, 2)
+	result.AssertStderrContains(t, "flag provided but not defined: -bad")
+
+	result = Run(t, newTree(), []string{"missing"})
+	result.AssertExitCode(t, 1)
+	result.AssertLogContains(t, "Unknown subcommand provided: missing.")
+}
+
+func TestHelpGolden(t *testing.T) {
+	t.Parallel()
+	result := Run(t, newTree(), []string{"echo", "-h"})
+	result.AssertExitCode(t, 0)
+	AssertGolden(t, "echo-help", result.Output())
+}
@@ -0,0 +1,103 @@
+package glarg
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Executor runs a Subcommand. The innermost Executor of a
+// Middleware chain calls cmd.Execute.
+type Executor func(ctx context.Context, cmd Subcommand) int
+
+// Middleware wraps an Executor, for example to time commands
+// or check credentials before running them.
+type Middleware func(next Executor) Executor
+
+// Hook runs around a Subcommand's Execute. An error from a
+// PreRun hook stops the command, and one from a PostRun hook
+// turns a successful run into a failure. Finally hooks always
+// run and their errors are only logged.
+type Hook func(ctx context.Context, cmd Subcommand) error
+
+type commandPathKey struct{}
+
+// CommandPath returns the names of the Subcommands from the
+// root down to the command being executed.
+func CommandPath(ctx context.Context) []string {
+	if path, ok := ctx.Value(commandPathKey{}).([]string); ok {
+		return path
+	}
+	return []string{}
+}
+
+func withCommandPath(ctx context.Context, name string) context.Context {
+	path := CommandPath(ctx)
+	return context.WithValue(ctx, commandPathKey{}, append(path[:len(path):len(path)], name))
+}
+
+// lifecycle is the Middleware and Hooks of a Subcommands and
+// all of its parents.
+type lifecycle struct {
+	middleware []Middleware
+	preRun     []Hook
+	postRun    []Hook
+	finally    []Hook
+}
+
+// extend adds the Middleware and Hooks of sc after the ones
+// inherited from its parents. It never modifies self.
+func (self lifecycle) extend(sc *Subcommands) lifecycle {
+	return lifecycle{
+		middleware: append(self.middleware[:len(self.middleware):len(self.middleware)], sc.Middleware...),
+		preRun:     append(self.preRun[:len(self.preRun):len(self.preRun)], sc.PreRun...),
+		postRun:    append(self.postRun[:len(self.postRun):len(self.postRun)], sc.PostRun...),
+		finally:    append(self.finally[:len(self.finally):len(self.finally)], sc.Finally...),
+	}
+}
+
+// run executes cmd inside the Middleware, outermost first, with
+// the PreRun and PostRun hooks, parents first. A Destructive cmd is
+// confirmed after the PreRun hooks. Finally hooks run last,
+// children first, even when the command panics or is cancelled.
+func (self lifecycle) run(ctx context.Context, cmd Subcommand) int {
+	// Deferred, so the last ones added run first.
+	for _, v := range self.finally {
+		defer func(hook Hook) {
+			if err := hook(ctx, cmd); err != nil {
+				Env(ctx).logf(slog.LevelError, "%s", err)
+			}
+		}(v)
+	}
+
+	var exec Executor = func(ctx context.Context, cmd Subcommand) int {
+		for _, v := range self.preRun {
+			if err := v(ctx, cmd); err != nil {
+				Env(ctx).logf(slog.LevelError, "%s", err)
+				return 1
+			}
+		}
+
+		// Nothing is destroyed without the user agreeing to it.
+		if _, ok := cmd.(Destructive); ok && !confirm(ctx, Env(ctx), cmd) {
+			return 1
+		}
+
+		rc := cmd.Execute(ctx)
+		if rc != 0 {
+			return rc
+		}
+
+		for _, v := range self.postRun {
+			if err := v(ctx, cmd); err != nil {
+				Env(ctx).logf(slog.LevelError, "%s", err)
+				return 1
+			}
+		}
+		return rc
+	}
+
+	for i := len(self.middleware) - 1; i >= 0; i-- {
+		exec = self.middleware[i](exec)
+	}
+	return exec(ctx, cmd)
+}
@@ -0,0 +1,90 @@
+package glarg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLifecycle(t *testing.T) {
+	trace := []string{}
+	hook := func(name string, err error) Hook {
+		return func(ctx context.Context, cmd Subcommand) error {
+			trace = append(trace, name)
+			return err
+		}
+	}
+	middleware := func(name string) Middleware {
+		return func(next Executor) Executor {
+			return func(ctx context.Context, cmd Subcommand) int {
+				trace = append(trace, name+">")
+				rc := next(ctx, cmd)
+				trace = append(trace, fmt.Sprintf("<%s:%d", name, rc))
+				return rc
+			}
+		}
+	}
+
+	var path []string
+	cmd := &SubcommandNoOp{Name: "leaf"}
+	nested := &Subcommands{
+		Name:       "nested",
+		Children:   []Subcommand{cmd},
+		Middleware: []Middleware{middleware("nested")},
+		PreRun:     []Hook{hook("nested-pre", nil)},
+		PostRun:    []Hook{hook("nested-post", nil)},
+		Finally:    []Hook{hook("nested-finally", nil)},
+	}
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{nested},
+		Middleware: []Middleware{middleware("root"), func(next Executor) Executor {
+			return func(ctx context.Context, cmd Subcommand) int {
+				path = CommandPath(ctx)
+				return next(ctx, cmd)
+			}
+		}},
+		PreRun:  []Hook{hook("root-pre", nil)},
+		PostRun: []Hook{hook("root-post", nil)},
+		Finally: []Hook{hook("root-finally", nil)},
+	}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "nested", "leaf"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	expected := "root> nested> root-pre nested-pre root-post nested-post <nested:0 <root:0 nested-finally root-finally"
+	if strings.Join(trace, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %s.", expected, strings.Join(trace, " "))
+	}
+	if strings.Join(path, " ") != "root nested leaf" {
+		t.Errorf("Error. Expected: root nested leaf. Received: %v.", path)
+	}
+
+	// A failing PreRun stops the command, PostRun is skipped and
+	// Finally still runs.
+	trace = []string{}
+	nested.PreRun = []Hook{hook("nested-pre", fmt.Errorf("not logged in"))}
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "leaf"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	expected = "root> nested> root-pre nested-pre <nested:1 <root:1 nested-finally root-finally"
+	if strings.Join(trace, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %s.", expected, strings.Join(trace, " "))
+	}
+
+	// A failing command skips PostRun.
+	trace = []string{}
+	nested.PreRun = nil
+	cmd.ExecuteInt = 3
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "leaf"})
+	if rc != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", rc)
+	}
+	expected = "root> nested> root-pre <nested:3 <root:3 nested-finally root-finally"
+	if strings.Join(trace, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %s.", expected, strings.Join(trace, " "))
+	}
+}
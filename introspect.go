@@ -0,0 +1,233 @@
+package glarg
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// SCHEMA_VERSION is the version of the document written by
+// WriteSchema. It changes whenever a field is removed or
+// changes meaning.
+const SCHEMA_VERSION = 1
+
+// Schema is the versioned description of a command tree.
+type Schema struct {
+	Version int         `json:"version"`
+	Command CommandInfo `json:"command"`
+}
+
+// CommandInfo describes one command of the tree. Path holds the
+// names of the commands from the root down to this one.
+type CommandInfo struct {
+	Name        string           `json:"name"`
+	Path        []string         `json:"path"`
+	Description string           `json:"description"`
+	Default     string           `json:"default,omitempty"`
+	Flags       []FlagInfo       `json:"flags"`
+	Constraints []FlagConstraint `json:"constraints,omitempty"`
+	Examples    []Example        `json:"examples,omitempty"`
+	Children    []CommandInfo    `json:"children,omitempty"`
+}
+
+// FlagInfo describes one flag. Type is the name of the flag's
+// value type, like UUIDFlag or SliceFlag, or string, int, bool,
+// duration and so on for the flag package's own values. For a
+// SliceFlag, Target is the SliceFlagTarget type and Element the
+// type of the items it holds. The default of a sensitive flag
+// is redacted.
+type FlagInfo struct {
+	Name      string       `json:"name"`
+	Usage     string       `json:"usage"`
+	Default   string       `json:"default"`
+	Type      string       `json:"type"`
+	Target    string       `json:"target,omitempty"`
+	Element   string       `json:"element,omitempty"`
+	Delimiter string       `json:"delimiter,omitempty"`
+	Choices   []EnumChoice `json:"choices,omitempty"`
+	Boolean   bool         `json:"boolean,omitempty"`
+	Sensitive bool         `json:"sensitive,omitempty"`
+}
+
+// Example is a command line showing how a command is used.
+type Example struct {
+	Command     string `json:"command"`
+	Description string `json:"description,omitempty"`
+}
+
+// Exampler is implemented by a Subcommand which has examples for
+// its documentation.
+type Exampler interface {
+	Examples() []Example
+}
+
+// Describe returns the description of cmd and every command
+// below it. The command tree must already be setup.
+func Describe(cmd Subcommand) CommandInfo {
+	return describe(cmd, nil)
+}
+
+func describe(cmd Subcommand, path []string) CommandInfo {
+	fs := cmd.FlagSet()
+	info := CommandInfo{
+		Description: cmd.Description(),
+		Flags:       []FlagInfo{},
+	}
+	if fs != nil {
+		info.Name = fs.Name()
+		fs.VisitAll(func(f *flag.Flag) {
+			info.Flags = append(info.Flags, describeFlag(f))
+		})
+	}
+	info.Path = append(path[:len(path):len(path)], info.Name)
+
+	if fc, ok := cmd.(FlagConstrainer); ok {
+		info.Constraints = fc.FlagConstraints()
+	}
+	if e, ok := cmd.(Exampler); ok {
+		info.Examples = e.Examples()
+	}
+	if sc, ok := cmd.(*Subcommands); ok {
+		info.Default = sc.Default
+		for _, v := range sc.Children {
+			info.Children = append(info.Children, describe(v, info.Path))
+		}
+	}
+	return info
+}
+
+func describeFlag(f *flag.Flag) FlagInfo {
+	info := FlagInfo{
+		Name:      f.Name,
+		Usage:     f.Usage,
+		Default:   f.DefValue,
+		Type:      typeName(f.Value),
+		Sensitive: sensitiveFlag(f.Name, f.Value),
+	}
+	if info.Sensitive && info.Default != "" {
+		info.Default = REDACTED
+	}
+	if bf, ok := f.Value.(boolFlag); ok && bf.IsBoolFlag() {
+		info.Boolean = true
+	}
+
+	var choices interface{} = f.Value
+	if sf, ok := f.Value.(*SliceFlag); ok {
+		info.Delimiter = sf.delimiter
+		if info.Delimiter == "" {
+			info.Delimiter = DEFAULT_DELIMITER
+		}
+		if sf.target != nil {
+			choices = sf.target
+			info.Target = typeName(sf.target)
+			if t := reflect.TypeOf(sf.target.Get()); t != nil && t.Kind() == reflect.Slice {
+				info.Element = t.Elem().String()
+			}
+		}
+	}
+	if c, ok := choices.(interface{ Choices() []EnumChoice }); ok {
+		info.Choices = c.Choices()
+	}
+	return info
+}
+
+// typeName returns the name of the type of v without the glarg
+// package, and the flag package's values by their Go type, like
+// string for its stringValue.
+func typeName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.PkgPath() {
+	case reflect.TypeOf(Schema{}).PkgPath():
+		return t.Name()
+	case "flag":
+		return strings.TrimSuffix(t.Name(), "Value")
+	}
+	return t.String()
+}
+
+// Walk calls fn for self and every command below it.
+func (self CommandInfo) Walk(fn func(info CommandInfo)) {
+	fn(self)
+	for _, v := range self.Children {
+		v.Walk(fn)
+	}
+}
+
+// Flag returns the flag called name, or nil.
+func (self CommandInfo) Flag(name string) *FlagInfo {
+	for i, v := range self.Flags {
+		if v.Name == name {
+			return &self.Flags[i]
+		}
+	}
+	return nil
+}
+
+// NewSchema describes cmd in the current SCHEMA_VERSION.
+func NewSchema(cmd Subcommand) Schema {
+	return Schema{
+		Version: SCHEMA_VERSION,
+		Command: Describe(cmd),
+	}
+}
+
+// ReadSchema reads a document written by WriteSchema.
+func ReadSchema(r io.Reader) (Schema, error) {
+	schema := Schema{}
+	if err := json.NewDecoder(r).Decode(&schema); err != nil {
+		return schema, err
+	}
+	if schema.Version != SCHEMA_VERSION {
+		return schema, fmt.Errorf("unsupported schema version %d", schema.Version)
+	}
+	return schema, nil
+}
+
+// WriteSchema writes the Schema of cmd to w as indented JSON.
+func WriteSchema(w io.Writer, cmd Subcommand) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewSchema(cmd))
+}
+
+// SchemaCommand is a Subcommand which prints the Schema of Root
+// as JSON.
+type SchemaCommand struct {
+	flagSet *flag.FlagSet
+	Name    string
+	Root    Subcommand
+}
+
+func (self *SchemaCommand) Description() string {
+	return "Print the description of the commands as JSON."
+}
+
+func (self *SchemaCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *SchemaCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	return self
+}
+
+func (self *SchemaCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *SchemaCommand) Execute(ctx context.Context) int {
+	env := Env(ctx)
+	if err := WriteSchema(env.Stdout, self.Root); err != nil {
+		env.logf(slog.LevelError, "Unable to write the schema. %s", err)
+		return 1
+	}
+	return 0
+}
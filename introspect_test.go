@@ -0,0 +1,108 @@
+package glarg
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type introspectTestCommand struct {
+	SubcommandNoOp
+	ids      []uuid.UUID
+	id       uuid.UUID
+	format   string
+	verbose  bool
+	password string
+}
+
+func (self *introspectTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewSliceFlag(&UUIDSliceFlagTarget{&self.ids}, ";"), "ids", "The ids.")
+	self.flagSet.Var(NewUUIDFlag(&self.id), "id", "The id.")
+	self.format = "text"
+	self.flagSet.Var(NewEnumFlag(&self.format, "text", "json"), "format", "The format.")
+	self.flagSet.BoolVar(&self.verbose, "verbose", false, "Be verbose.")
+	self.flagSet.StringVar(&self.password, "password", "hunter2", "The password.")
+	return self
+}
+
+func (self *introspectTestCommand) FlagConstraints() []FlagConstraint {
+	return []FlagConstraint{MutuallyExclusive("id", "ids")}
+}
+
+func TestDescribe(t *testing.T) {
+	root := &Subcommands{
+		Name:    "root",
+		Default: "get",
+		Children: []Subcommand{
+			&introspectTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}},
+			&Subcommands{Name: "remote", Children: []Subcommand{&SubcommandNoOp{Name: "add"}}},
+		},
+	}
+	root.SetupSubcommand()
+	info := Describe(root)
+
+	paths := []string{}
+	info.Walk(func(v CommandInfo) {
+		paths = append(paths, strings.Join(v.Path, " "))
+	})
+	if strings.Join(paths, ",") != "root,root get,root remote,root remote add" {
+		t.Errorf("Error. Expected the paths. Received: %v.", paths)
+	}
+	if info.Default != "get" {
+		t.Errorf("Error. Expected: get. Received: %s.", info.Default)
+	}
+
+	get := info.Children[0]
+	expected := map[string]FlagInfo{
+		"ids":      {Name: "ids", Usage: "The ids.", Default: "", Type: "SliceFlag", Target: "UUIDSliceFlagTarget", Element: "uuid.UUID", Delimiter: ";"},
+		"id":       {Name: "id", Usage: "The id.", Default: uuid.Nil.String(), Type: "UUIDFlag"},
+		"format":   {Name: "format", Usage: "The format.", Default: "text", Type: "EnumFlag", Choices: []EnumChoice{{Value: "text"}, {Value: "json"}}},
+		"verbose":  {Name: "verbose", Usage: "Be verbose.", Default: "false", Type: "bool", Boolean: true},
+		"password": {Name: "password", Usage: "The password.", Default: REDACTED, Type: "string", Sensitive: true},
+	}
+	for k, v := range expected {
+		f := get.Flag(k)
+		if f == nil || !reflect.DeepEqual(*f, v) {
+			t.Errorf("Error. Flag: %s. Expected: %+v. Received: %+v.", k, v, f)
+		}
+	}
+	if len(get.Constraints) != 1 || get.Constraints[0].Kind != ConstraintMutuallyExclusive {
+		t.Errorf("Error. Expected the constraint. Received: %v.", get.Constraints)
+	}
+	if get.Flag("missing") != nil {
+		t.Errorf("Error. Expected no flag.")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{&introspectTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}},
+	}
+	root.SetupSubcommand()
+
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf, root); err != nil {
+		t.Fatalf("Error. %s", err)
+	}
+	if !strings.Contains(buf.String(), `"kind": "mutually_exclusive"`) {
+		t.Errorf("Error. Expected the constraint kind by name. Received: %s.", buf.String())
+	}
+
+	schema, err := ReadSchema(&buf)
+	if err != nil {
+		t.Fatalf("Error. %s", err)
+	}
+	if !reflect.DeepEqual(schema, NewSchema(root)) {
+		t.Errorf("Error. Expected: %+v. Received: %+v.", NewSchema(root), schema)
+	}
+
+	if _, err := ReadSchema(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Errorf("Error. Expected an unsupported version.")
+	}
+}
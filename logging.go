@@ -0,0 +1,147 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// LOG_LEVEL_TRACE is the level enabled by -vv, below slog.LevelDebug.
+const LOG_LEVEL_TRACE = slog.LevelDebug - 4
+
+// LogConfig turns on the logging flags of a Subcommands. Every
+// command below it gets -v, -vv, -log-level and -log-format, and
+// runs with an slog.Logger configured from them, see Logger. The
+// records include the command path and the invocation id, and
+// glarg's own messages become records too.
+type LogConfig struct {
+	// The level without any flags. Defaults to slog.LevelInfo.
+	Level slog.Level
+	// "text" or "json". Defaults to "text".
+	Format string
+	// Add the source file and line to the records.
+	AddSource bool
+}
+
+// logFlags holds the values of the logging flags of one setup of
+// the tree.
+type logFlags struct {
+	config  LogConfig
+	verbose bool
+	trace   bool
+	level   string
+	format  string
+}
+
+func newLogFlags(config LogConfig) *logFlags {
+	format := config.Format
+	if format == "" {
+		format = "text"
+	}
+	return &logFlags{config: config, format: format}
+}
+
+// addTo adds the flags to every command below cmd. See addFlags.
+func (self *logFlags) addTo(cmd Subcommand) {
+	addFlags(cmd, func(cmd Subcommand, fs *flag.FlagSet) {
+		fs.BoolVar(&self.verbose, "v", false, "Log debug messages.")
+		fs.BoolVar(&self.trace, "vv", false, "Log trace messages.")
+		level := NewEnumFlag(&self.level, "trace", "debug", "info", "warn", "error").IgnoreCase()
+		fs.Var(level, "log-level", level.Usage("The lowest level to log"))
+		format := NewEnumFlag(&self.format, "text", "json").IgnoreCase()
+		fs.Var(format, "log-format", format.Usage("The format of the log"))
+	})
+}
+
+func (self *logFlags) levelValue() slog.Level {
+	switch {
+	case self.level == "trace":
+		return LOG_LEVEL_TRACE
+	case self.level != "":
+		var level slog.Level
+		level.UnmarshalText([]byte(self.level))
+		return level
+	case self.trace:
+		return LOG_LEVEL_TRACE
+	case self.verbose:
+		return slog.LevelDebug
+	}
+	return self.config.Level
+}
+
+// attach returns ctx with an Environment whose Slog is configured
+// from the flags, and tagged with the command path.
+func (self *logFlags) attach(ctx context.Context) context.Context {
+	env := *Env(ctx)
+	opts := &slog.HandlerOptions{
+		Level:     self.levelValue(),
+		AddSource: self.config.AddSource,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 {
+				if level, ok := a.Value.Any().(slog.Level); ok && level <= LOG_LEVEL_TRACE {
+					a.Value = slog.StringValue("TRACE")
+				}
+			}
+			return a
+		},
+	}
+
+	var handler slog.Handler
+	if self.format == "json" {
+		handler = slog.NewJSONHandler(env.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(env.Stderr, opts)
+	}
+	env.Slog = slog.New(handler).With(
+		slog.String("command", strings.Join(CommandPath(ctx), " ")),
+		slog.String("invocation", InvocationID(ctx)),
+	)
+	return context.WithValue(ctx, environmentKey{}, &env)
+}
+
+type invocationIDKey struct{}
+
+// InvocationID returns the id Invoke gave to this run, which tells
+// the log records of one run apart from the others.
+func InvocationID(ctx context.Context) string {
+	if id, ok := ctx.Value(invocationIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+func withInvocationID(ctx context.Context) context.Context {
+	return context.WithValue(ctx, invocationIDKey{}, uuid.NewString())
+}
+
+// Logger returns the slog.Logger of the command. Without a
+// LogConfig it is slog.Default.
+func Logger(ctx context.Context) *slog.Logger {
+	if env := Env(ctx); env.Slog != nil {
+		return env.Slog
+	}
+	return slog.Default()
+}
+
+// logf reports one of glarg's own messages. It is a record of the
+// Slog when there is one, and a styled line of the Logger
+// otherwise.
+func (self *Environment) logf(level slog.Level, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if self.Slog != nil {
+		self.Slog.Log(context.Background(), level, message)
+		return
+	}
+	styler := self.Styler(self.Logger.Writer())
+	switch {
+	case level >= slog.LevelError:
+		message = styler.Style(STYLE_ERROR, message)
+	case level >= slog.LevelWarn:
+		message = styler.Style(STYLE_WARNING, message)
+	}
+	self.Logger.Print(message)
+}
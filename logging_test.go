@@ -0,0 +1,128 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type loggingTestCommand struct {
+	SubcommandNoOp
+}
+
+func (self *loggingTestCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	return self
+}
+
+func (self *loggingTestCommand) Execute(ctx context.Context) int {
+	logger := Logger(ctx)
+	logger.Log(ctx, LOG_LEVEL_TRACE, "trace message")
+	logger.Debug("debug message")
+	logger.Info("info message")
+	return 0
+}
+
+func TestLogging(t *testing.T) {
+	newRoot := func() *Subcommands {
+		return &Subcommands{
+			Name:    "root",
+			Logging: &LogConfig{},
+			Children: []Subcommand{
+				&loggingTestCommand{SubcommandNoOp{Name: "get"}},
+				&Subcommands{Name: "remote", Children: []Subcommand{&loggingTestCommand{SubcommandNoOp{Name: "add"}}}},
+			},
+		}
+	}
+
+	cases := []struct {
+		args     []string
+		expected []string
+		missing  []string
+	}{
+		{[]string{"get"}, []string{"level=INFO msg=\"info message\" command=\"root get\" invocation="}, []string{"debug message"}},
+		{[]string{"get", "-v"}, []string{"debug message", "info message"}, []string{"trace message"}},
+		{[]string{"get", "-vv"}, []string{"level=TRACE msg=\"trace message\""}, []string{}},
+		{[]string{"get", "-log-level", "WARN"}, []string{}, []string{"info message"}},
+		{[]string{"remote", "add", "-v"}, []string{"msg=\"debug message\" command=\"root remote add\""}, []string{}},
+		{[]string{"remote", "add", "-log-level", "debug"}, []string{"debug message"}, []string{}},
+		{[]string{"missing"}, []string{"Unknown subcommand provided: missing."}, []string{"level="}},
+	}
+	for _, v := range cases {
+		var stderr, logs bytes.Buffer
+		env := &Environment{Stderr: &stderr, Logger: log.New(&logs, "", 0)}
+		if rc := Invoke(context.Background(), newRoot(), append([]string{"tool"}, v.args...), WithEnvironment(env)); rc != 0 && v.args[0] != "missing" {
+			t.Errorf("Error. Args: %v. Expected: 0. Received: %d.", v.args, rc)
+		}
+		output := stderr.String() + logs.String()
+		for _, e := range v.expected {
+			if !strings.Contains(output, e) {
+				t.Errorf("Error. Args: %v. Expected: %s. Received: %s.", v.args, e, output)
+			}
+		}
+		for _, e := range v.missing {
+			if strings.Contains(output, e) {
+				t.Errorf("Error. Args: %v. Expected no: %s. Received: %s.", v.args, e, output)
+			}
+		}
+	}
+}
+
+func TestLoggingJSON(t *testing.T) {
+	var stderr bytes.Buffer
+	root := &Subcommands{
+		Name:     "root",
+		Logging:  &LogConfig{Level: slog.LevelWarn},
+		Children: []Subcommand{&loggingTestCommand{SubcommandNoOp{Name: "get"}}, &constraintTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}},
+	}
+	env := &Environment{Stderr: &stderr}
+
+	Invoke(context.Background(), root, []string{"tool", "get", "-log-format", "json", "-v"}, WithEnvironment(env))
+	record := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(strings.Split(stderr.String(), "\n")[0]), &record); err != nil {
+		t.Fatalf("Error. Expected JSON. Received: %s.", stderr.String())
+	}
+	if record["msg"] != "debug message" || record["command"] != "root get" || record["invocation"] == "" {
+		t.Errorf("Error. Expected the debug record. Received: %v.", record)
+	}
+
+	// glarg's own errors are records too.
+	stderr.Reset()
+	if rc := Invoke(context.Background(), root, []string{"tool", "login", "-log-format", "json"}, WithEnvironment(env)); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(stderr.String(), `"level":"ERROR","msg":"Invalid arguments.","command":"root login"`) {
+		t.Errorf("Error. Expected the invalid arguments record. Received: %s.", stderr.String())
+	}
+}
+
+func TestLoggingFlagsOnlyOnCommands(t *testing.T) {
+	root := &Subcommands{
+		Name:     "root",
+		Logging:  &LogConfig{},
+		Children: []Subcommand{&Subcommands{Name: "remote", Children: []Subcommand{&loggingTestCommand{SubcommandNoOp{Name: "add"}}}}},
+	}
+	root.SetupSubcommand()
+
+	if root.FlagSet().Lookup("v") != nil || root.Children[0].FlagSet().Lookup("v") != nil {
+		t.Errorf("Error. Expected no -v on the Subcommands.")
+	}
+	if root.Children[0].(*Subcommands).Children[0].FlagSet().Lookup("v") == nil {
+		t.Errorf("Error. Expected -v on the command.")
+	}
+}
+
+func TestLoggingFlagsHelp(t *testing.T) {
+	var stderr bytes.Buffer
+	root := &Subcommands{Name: "root", Logging: &LogConfig{}, Children: []Subcommand{&loggingTestCommand{SubcommandNoOp{Name: "get"}}}}
+	Invoke(context.Background(), root, []string{"tool", "get", "-h"}, WithEnvironment(&Environment{Stderr: &stderr}), ContinueOnError())
+	for _, v := range []string{"The lowest level to log (one of: trace, debug, info, warn, error)", "The format of the log (one of: text, json)"} {
+		if !strings.Contains(stderr.String(), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, stderr.String())
+		}
+	}
+}
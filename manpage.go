@@ -0,0 +1,314 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ManEntry is one item of the ENVIRONMENT or EXIT STATUS
+// section of a man page.
+type ManEntry struct {
+	Name        string
+	Description string
+}
+
+// DefaultExitCodes are the exit codes documented when
+// ManOptions.ExitCodes is nil.
+var DefaultExitCodes = []ManEntry{
+	{"0", "Success."},
+	{"1", "The command failed, or its arguments are invalid."},
+	{"2", "The flags could not be parsed."},
+	{strconv.Itoa(EXIT_PANIC), "The command crashed."},
+}
+
+// ManOptions are the parts of a man page which can not be
+// found in the command tree.
+type ManOptions struct {
+	// Defaults to "1".
+	Section string
+	// Defaults to $SOURCE_DATE_EPOCH, so that packages build
+	// reproducibly, and otherwise to now.
+	Date time.Time
+	// Usually the name and version of the program.
+	Source string
+	// The title of the manual, like "User Commands".
+	Manual string
+	// The environment variables read by every command.
+	Environment []ManEntry
+	// Defaults to DefaultExitCodes.
+	ExitCodes []ManEntry
+}
+
+func (self ManOptions) section() string {
+	if self.Section == "" {
+		return "1"
+	}
+	return self.Section
+}
+
+func (self ManOptions) date() time.Time {
+	if !self.Date.IsZero() {
+		return self.Date
+	}
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now()
+}
+
+// ManPageName returns the file name of the man page of info,
+// which is its path joined by "-", like tool-remote-add.1.
+func ManPageName(info CommandInfo, section string) string {
+	if section == "" {
+		section = "1"
+	}
+	return strings.Join(info.Path, "-") + "." + section
+}
+
+// roff escapes s for use as text in a man page.
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, v := range lines {
+		if strings.HasPrefix(v, ".") || strings.HasPrefix(v, "'") {
+			lines[i] = `\&` + v
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteManPage writes the roff man page of the command described
+// by info. parent is the command above it, or nil for the root.
+func WriteManPage(w io.Writer, info CommandInfo, parent *CommandInfo, opts ManOptions) error {
+	name := strings.Join(info.Path, "-")
+	section := opts.section()
+	lines := []string{
+		fmt.Sprintf(`.TH "%s" "%s" "%s" "%s" "%s"`, roff(strings.ToUpper(name)), section,
+			opts.date().Format("2006-01-02"), roff(opts.Source), roff(opts.Manual)),
+		".SH NAME",
+		fmt.Sprintf(`%s \- %s`, roff(name), roff(info.Description)),
+		".SH SYNOPSIS",
+	}
+
+	synopsis := `\fB` + roff(strings.Join(info.Path, " ")) + `\fR`
+	if len(info.Flags) > 0 {
+		synopsis += ` [\fIflags\fR]`
+	}
+	if len(info.Children) > 0 {
+		synopsis += ` \fIcommand\fR`
+	}
+	lines = append(lines, synopsis+` [\fIargs\fR]`, ".SH DESCRIPTION", roff(info.Description))
+	if info.Default != "" {
+		lines = append(lines, ".PP", roff(fmt.Sprintf("Runs %s when no command is given.", info.Default)))
+	}
+
+	if len(info.Children) > 0 {
+		lines = append(lines, ".SH COMMANDS")
+		for _, v := range info.Children {
+			lines = append(lines, ".TP", `\fB`+roff(v.Name)+`\fR`, roff(v.Description))
+		}
+	}
+
+	if len(info.Flags) > 0 {
+		lines = append(lines, ".SH OPTIONS")
+		for _, v := range info.Flags {
+			lines = append(lines, manFlag(v)...)
+		}
+	}
+
+	if len(info.Constraints) > 0 {
+		lines = append(lines, ".SH CONSTRAINTS")
+		for _, v := range info.Constraints {
+			lines = append(lines, ".IP \\(bu 2", roff(constraintText(v)))
+		}
+	}
+
+	if len(info.Examples) > 0 {
+		lines = append(lines, ".SH EXAMPLES")
+		for _, v := range info.Examples {
+			if v.Description != "" {
+				lines = append(lines, ".PP", roff(v.Description))
+			}
+			lines = append(lines, ".PP", ".nf", ".RS 4", roff(v.Command), ".RE", ".fi")
+		}
+	}
+
+	if len(opts.Environment) > 0 {
+		lines = append(lines, ".SH ENVIRONMENT")
+		lines = append(lines, manEntries(opts.Environment)...)
+	}
+
+	exitCodes := opts.ExitCodes
+	if exitCodes == nil {
+		exitCodes = DefaultExitCodes
+	}
+	if len(exitCodes) > 0 {
+		lines = append(lines, ".SH EXIT STATUS")
+		lines = append(lines, manEntries(exitCodes)...)
+	}
+
+	seeAlso := []string{}
+	if parent != nil {
+		seeAlso = append(seeAlso, manReference(*parent, section))
+	}
+	for _, v := range info.Children {
+		seeAlso = append(seeAlso, manReference(v, section))
+	}
+	if len(seeAlso) > 0 {
+		lines = append(lines, ".SH SEE ALSO", strings.Join(seeAlso, ",\n"))
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func manFlag(f FlagInfo) []string {
+	head := `\fB\-` + roff(f.Name) + `\fR`
+	if !f.Boolean {
+		head += ` \fIvalue\fR`
+	}
+
+	text := []string{roff(f.Usage)}
+	text = append(text, ".br", roff("Type: "+f.typeText()+"."))
+	if len(f.Choices) > 0 {
+		text = append(text, ".br", roff("One of: "+f.choicesText()+"."))
+	}
+	if f.hasDefault() {
+		text = append(text, ".br", roff("Default: "+f.Default+"."))
+	}
+	return append([]string{".TP", head}, text...)
+}
+
+// typeText describes the type of the flag for the documentation.
+func (self FlagInfo) typeText() string {
+	if self.Element != "" {
+		return fmt.Sprintf("%s of %s, separated by %q", self.Type, self.Element, self.Delimiter)
+	}
+	return self.Type
+}
+
+// hasDefault is false when the default is not worth showing,
+// like the false of a boolean flag.
+func (self FlagInfo) hasDefault() bool {
+	return self.Default != "" && !(self.Boolean && self.Default == "false")
+}
+
+func (self FlagInfo) choicesText() string {
+	values := make([]string, len(self.Choices))
+	for i, v := range self.Choices {
+		values[i] = v.Value
+	}
+	return strings.Join(values, ", ")
+}
+
+func manEntries(entries []ManEntry) []string {
+	lines := []string{}
+	for _, v := range entries {
+		lines = append(lines, ".TP", `\fB`+roff(v.Name)+`\fR`, roff(v.Description))
+	}
+	return lines
+}
+
+func manReference(info CommandInfo, section string) string {
+	return fmt.Sprintf(`\fB%s\fR(%s)`, roff(strings.Join(info.Path, "-")), section)
+}
+
+func constraintText(c FlagConstraint) string {
+	names := make([]string, len(c.Flags))
+	for i, v := range c.Flags {
+		names[i] = "-" + v
+	}
+	list := strings.Join(names, ", ")
+	switch c.Kind {
+	case ConstraintRequired:
+		return "Required: " + list + "."
+	case ConstraintMutuallyExclusive:
+		return "At most one of: " + list + "."
+	case ConstraintRequiredTogether:
+		return "All or none of: " + list + "."
+	case ConstraintAtLeastOneOf:
+		return "At least one of: " + list + "."
+	case ConstraintExactlyOneOf:
+		return "Exactly one of: " + list + "."
+	case ConstraintRequires:
+		return fmt.Sprintf("-%s requires: %s.", c.If, list)
+	}
+	return c.Kind.String() + ": " + list + "."
+}
+
+// WriteManPages writes the man page of root and of every command
+// below it into dir, and returns the paths of the files.
+func WriteManPages(dir string, root Subcommand, opts ManOptions) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return writeManPages(dir, Describe(root), nil, opts)
+}
+
+func writeManPages(dir string, info CommandInfo, parent *CommandInfo, opts ManOptions) ([]string, error) {
+	path := filepath.Join(dir, ManPageName(info, opts.section()))
+	if err := writeFile(path, func(w io.Writer) error {
+		return WriteManPage(w, info, parent, opts)
+	}); err != nil {
+		return nil, err
+	}
+
+	written := []string{path}
+	for _, v := range info.Children {
+		paths, err := writeManPages(dir, v, &info, opts)
+		written = append(written, paths...)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ManCommand is a Subcommand which writes the man pages of Root
+// into the directory given with -dir.
+type ManCommand struct {
+	flagSet *flag.FlagSet
+	Name    string
+	Root    Subcommand
+	Options ManOptions
+	dir     string
+}
+
+func (self *ManCommand) Description() string {
+	return "Write the man pages."
+}
+
+func (self *ManCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *ManCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.StringVar(&self.dir, "dir", ".", "The directory to write the man pages into.")
+	return self
+}
+
+func (self *ManCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *ManCommand) Execute(ctx context.Context) int {
+	env := Env(ctx)
+	paths, err := WriteManPages(self.dir, self.Root, self.Options)
+	if err != nil {
+		env.logf(slog.LevelError, "Unable to write the man pages. %s", err)
+		return 1
+	}
+	for _, v := range paths {
+		fmt.Fprintln(env.Stdout, v)
+	}
+	return 0
+}
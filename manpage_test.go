@@ -0,0 +1,83 @@
+package glarg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteManPages(t *testing.T) {
+	root := &Subcommands{
+		Name: "tool",
+		Children: []Subcommand{
+			&introspectTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}},
+			&Subcommands{Name: "remote", Children: []Subcommand{&SubcommandNoOp{Name: "add"}}},
+		},
+	}
+	root.SetupSubcommand()
+
+	dir := t.TempDir()
+	opts := ManOptions{
+		Date:        time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Source:      "tool 1.0",
+		Environment: []ManEntry{{"TOOL_HOME", "Where the state is kept."}},
+	}
+	paths, err := WriteManPages(dir, root, opts)
+	if err != nil {
+		t.Fatalf("Error. %s", err)
+	}
+
+	names := []string{}
+	for _, v := range paths {
+		names = append(names, filepath.Base(v))
+	}
+	if strings.Join(names, " ") != "tool.1 tool-get.1 tool-remote.1 tool-remote-add.1" {
+		t.Errorf("Error. Expected the pages. Received: %v.", names)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, "tool-get.1"))
+	for _, v := range []string{
+		`.TH "TOOL\-GET" "1" "2024-03-01" "tool 1.0" ""`,
+		`tool\-get \- Not actually implemented.`,
+		`\fBtool get\fR [\fIflags\fR] [\fIargs\fR]`,
+		".TP\n\\fB\\-ids\\fR \\fIvalue\\fR\nThe ids.\n.br\nType: SliceFlag of uuid.UUID, separated by \";\".",
+		"One of: text, json.\n.br\nDefault: text.",
+		".TP\n\\fB\\-verbose\\fR\nBe verbose.\n.br\nType: bool.\n.SH CONSTRAINTS",
+		"Default: [REDACTED].",
+		`At most one of: \-id, \-ids.`,
+		".SH ENVIRONMENT\n.TP\n\\fBTOOL_HOME\\fR",
+		".TP\n\\fB70\\fR\nThe command crashed.",
+		".SH SEE ALSO\n\\fBtool\\fR(1)\n",
+	} {
+		if !strings.Contains(string(content), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, content)
+		}
+	}
+
+	content, _ = os.ReadFile(filepath.Join(dir, "tool-remote.1"))
+	for _, v := range []string{
+		`\fBtool remote\fR \fIcommand\fR [\fIargs\fR]`,
+		".SH COMMANDS\n.TP\n\\fBadd\\fR\nNot actually implemented.",
+		".SH SEE ALSO\n\\fBtool\\fR(1),\n\\fBtool\\-remote\\-add\\fR(1)\n",
+	} {
+		if !strings.Contains(string(content), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, content)
+		}
+	}
+}
+
+func TestRoff(t *testing.T) {
+	cases := map[string]string{
+		`a-b\c`:    `a\-b\ec`,
+		".start":   `\&.start`,
+		"x\n'y":    "x\n\\&'y",
+		"plain it": "plain it",
+	}
+	for k, v := range cases {
+		if roff(k) != v {
+			t.Errorf("Error. Input: %q. Expected: %q. Received: %q.", k, v, roff(k))
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package glarg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Multicall makes Invoke pick the child of the root Subcommands
+// by the name the program was run as, busybox style. Running a
+// link named "status" to the program is the same as running
+// "program status". Otherwise the args are used as usual.
+func Multicall() InvokeOption {
+	return func(self *invocation) {
+		self.multicall = true
+	}
+}
+
+// multicallName is the name the program was run as, without
+// resolving any symlinks, since those are the point.
+func multicallName(arg0 string) string {
+	return strings.TrimSuffix(filepath.Base(arg0), ".exe")
+}
+
+func multicallArgs(cmd Subcommand, args []string) []string {
+	sc, ok := cmd.(*Subcommands)
+	if !ok || len(args) == 0 {
+		return args
+	}
+
+	name := multicallName(args[0])
+	for _, v := range sc.Children {
+		if v.FlagSet() != nil && v.FlagSet().Name() == name {
+			return append([]string{args[0], name}, args[1:]...)
+		}
+	}
+	return args
+}
+
+// InstallMulticallLinks creates a symlink in dir to target for
+// every child of root. When target is empty, the running
+// executable is used. Existing symlinks are replaced, anything
+// else with the same name is an error.
+func InstallMulticallLinks(root *Subcommands, dir string, target string) ([]string, error) {
+	if target == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, err
+		}
+		target = exe
+	}
+
+	if root.FlagSet() == nil {
+		root.SetupSubcommand()
+	}
+
+	links := []string{}
+	for _, v := range root.Children {
+		link := filepath.Join(dir, v.FlagSet().Name())
+		if info, err := os.Lstat(link); err == nil {
+			if info.Mode()&os.ModeSymlink == 0 {
+				return links, fmt.Errorf("%s already exists and is not a symlink", link)
+			}
+			if err := os.Remove(link); err != nil {
+				return links, err
+			}
+		}
+		if err := os.Symlink(target, link); err != nil {
+			return links, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
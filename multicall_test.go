@@ -0,0 +1,90 @@
+package glarg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMulticall(t *testing.T) {
+	root := &Subcommands{
+		Name: "root",
+		Children: []Subcommand{
+			&SubcommandNoOp{Name: "status", ExecuteInt: 3},
+			&SubcommandNoOp{Name: "purge", ExecuteInt: 4},
+		},
+	}
+
+	cases := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"/usr/local/bin/status"}, 3},
+		{[]string{"purge.exe"}, 4},
+		{[]string{"./tool", "status"}, 3},
+		{[]string{"./tool"}, 1},
+	}
+	for _, v := range cases {
+		if rc := Invoke(context.Background(), root, v.args, Multicall()); rc != v.expected {
+			t.Errorf("Error. Args: %v. Expected: %d. Received: %d.", v.args, v.expected, rc)
+		}
+	}
+
+	// Without the option argv[0] is only the program name.
+	if rc := Invoke(context.Background(), root, []string{"status"}); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	// The flags after the name go to the command, even with the
+	// name of another command as a value.
+	get := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	root.Children = append(root.Children, get)
+	args := []string{"/usr/local/bin/get", "-ids", "a,b", "-name", "status"}
+	if rc := Invoke(context.Background(), root, args, Multicall()); rc != 0 {
+		t.Errorf("Error. Args: %v. Expected: 0. Received: %d.", args, rc)
+	}
+	expected := "a,b|00000000-0000-0000-0000-000000000000|status"
+	if strings.Join(get.results, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %v.", expected, get.results)
+	}
+}
+
+func TestInstallMulticallLinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on windows")
+	}
+
+	dir := t.TempDir()
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{&SubcommandNoOp{Name: "status"}, &SubcommandNoOp{Name: "purge"}},
+	}
+
+	links, err := InstallMulticallLinks(root, dir, "/opt/tool/bin/tool")
+	if err != nil {
+		t.Fatalf("Error. Expected install to work. Received: %s", err)
+	}
+	if len(links) != 2 {
+		t.Errorf("Error. Expected: 2. Received: %d.", len(links))
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "status")); target != "/opt/tool/bin/tool" {
+		t.Errorf("Error. Expected: /opt/tool/bin/tool. Received: %s.", target)
+	}
+
+	// Installing again replaces the links.
+	if _, err := InstallMulticallLinks(root, dir, "/opt/tool2/bin/tool"); err != nil {
+		t.Errorf("Error. Expected install to work. Received: %s", err)
+	}
+	if target, _ := os.Readlink(filepath.Join(dir, "purge")); target != "/opt/tool2/bin/tool" {
+		t.Errorf("Error. Expected: /opt/tool2/bin/tool. Received: %s.", target)
+	}
+
+	// But leaves real files alone.
+	os.Remove(filepath.Join(dir, "purge"))
+	os.WriteFile(filepath.Join(dir, "purge"), nil, 0755)
+	if _, err := InstallMulticallLinks(root, dir, "/opt/tool/bin/tool"); err == nil {
+		t.Errorf("Error. Expected install to fail.")
+	}
+}
@@ -0,0 +1,508 @@
+package glarg
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// AddrOption restricts the addresses accepted by the IP, Prefix
+// and HostPort flags. Options can be combined.
+type AddrOption int
+
+const (
+	// Only accept IPv4 addresses (including IPv4-mapped IPv6).
+	IPv4Only AddrOption = 1 << iota
+	// Only accept IPv6 addresses.
+	IPv6Only
+	// Reject loopback addresses like 127.0.0.1 and ::1.
+	NoLoopback
+)
+
+func addrOptions(opts []AddrOption) AddrOption {
+	var all AddrOption
+	for _, v := range opts {
+		all |= v
+	}
+	return all
+}
+
+func (self AddrOption) check(addr netip.Addr) error {
+	is4 := addr.Is4() || addr.Is4In6()
+	if self&IPv4Only != 0 && !is4 {
+		return fmt.Errorf("%s is not an IPv4 address", addr)
+	}
+	if self&IPv6Only != 0 && is4 {
+		return fmt.Errorf("%s is not an IPv6 address", addr)
+	}
+	if self&NoLoopback != 0 && addr.Unmap().IsLoopback() {
+		return fmt.Errorf("%s is a loopback address", addr)
+	}
+	return nil
+}
+
+func (self AddrOption) parseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr, self.check(addr)
+}
+
+func (self AddrOption) parsePrefix(s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix, self.check(prefix.Addr())
+}
+
+// IP flag getter. Deals with parsing IP address inputs.
+// This type implements the flag.Getter interface.
+type IPFlag struct {
+	ptr  *netip.Addr
+	opts AddrOption
+}
+
+func NewIPFlag(v *netip.Addr, opts ...AddrOption) *IPFlag {
+	return &IPFlag{
+		ptr:  v,
+		opts: addrOptions(opts),
+	}
+}
+
+func (self IPFlag) String() string {
+	if self.ptr == nil || !self.ptr.IsValid() {
+		return ""
+	} else {
+		return self.ptr.String()
+	}
+}
+
+func (self *IPFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = &netip.Addr{}
+	}
+
+	if v, err := self.opts.parseAddr(s); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self IPFlag) Get() interface{} {
+	if self.ptr == nil {
+		return netip.Addr{}
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *IPFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = netip.Addr{}
+	}
+}
+
+// IPSliceFlagTarget is an IP Target for a
+// SliceFlag.
+type IPSliceFlagTarget struct {
+	Target  *[]netip.Addr
+	Options []AddrOption
+}
+
+func (self *IPSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]netip.Addr{}
+	}
+}
+
+func (self *IPSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *IPSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	if v, err := addrOptions(self.Options).parseAddr(item); err != nil {
+		return nil, err
+	} else {
+		*self.Target = append(*self.Target, v)
+	}
+	return self, nil
+}
+
+func (self *IPSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	pieces := make([]string, len(*self.Target))
+	for k, v := range *self.Target {
+		pieces[k] = v.String()
+	}
+	return strings.Join(pieces, del)
+}
+
+func (self *IPSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
+
+// Prefix flag getter. Deals with parsing CIDR inputs like
+// 10.0.0.0/8. This type implements the flag.Getter interface.
+type PrefixFlag struct {
+	ptr  *netip.Prefix
+	opts AddrOption
+}
+
+func NewPrefixFlag(v *netip.Prefix, opts ...AddrOption) *PrefixFlag {
+	return &PrefixFlag{
+		ptr:  v,
+		opts: addrOptions(opts),
+	}
+}
+
+func (self PrefixFlag) String() string {
+	if self.ptr == nil || !self.ptr.IsValid() {
+		return ""
+	} else {
+		return self.ptr.String()
+	}
+}
+
+func (self *PrefixFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = &netip.Prefix{}
+	}
+
+	if v, err := self.opts.parsePrefix(s); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self PrefixFlag) Get() interface{} {
+	if self.ptr == nil {
+		return netip.Prefix{}
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *PrefixFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = netip.Prefix{}
+	}
+}
+
+// PrefixSliceFlagTarget is a CIDR Target for a
+// SliceFlag.
+type PrefixSliceFlagTarget struct {
+	Target  *[]netip.Prefix
+	Options []AddrOption
+}
+
+func (self *PrefixSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]netip.Prefix{}
+	}
+}
+
+func (self *PrefixSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *PrefixSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	if v, err := addrOptions(self.Options).parsePrefix(item); err != nil {
+		return nil, err
+	} else {
+		*self.Target = append(*self.Target, v)
+	}
+	return self, nil
+}
+
+func (self *PrefixSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	pieces := make([]string, len(*self.Target))
+	for k, v := range *self.Target {
+		pieces[k] = v.String()
+	}
+	return strings.Join(pieces, del)
+}
+
+func (self *PrefixSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
+
+// HostPort is a host (name or IP address) and port pair.
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+func (self HostPort) String() string {
+	if self.Host == "" && self.Port == 0 {
+		return ""
+	}
+	return net.JoinHostPort(self.Host, strconv.Itoa(int(self.Port)))
+}
+
+// ParseHostPort parses host:port. When the port is missing
+// and defaultPort is not zero, defaultPort is used. Bare and
+// bracketed IPv6 addresses are accepted.
+func ParseHostPort(s string, defaultPort uint16, opts ...AddrOption) (HostPort, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		if defaultPort == 0 {
+			return HostPort{}, err
+		}
+		if strings.HasPrefix(s, "[") != strings.HasSuffix(s, "]") {
+			return HostPort{}, fmt.Errorf("unbalanced brackets in %q", s)
+		}
+		bare := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+		host, port = bare, strconv.Itoa(int(defaultPort))
+	}
+
+	// Brackets are only for the colons of IPv6 addresses.
+	if addr, err := netip.ParseAddr(host); strings.HasPrefix(s, "[") && (err != nil || !addr.Is6()) {
+		return HostPort{}, fmt.Errorf("only IPv6 addresses go in brackets, not %q in %q", host, s)
+	}
+
+	// Only an explicit port makes a listen address like ":8080".
+	if host == "" && port == "" {
+		return HostPort{}, fmt.Errorf("missing host and port in %q", s)
+	} else if host == "" && err != nil {
+		return HostPort{}, fmt.Errorf("missing host in %q", s)
+	}
+	if port == "" && defaultPort != 0 {
+		port = strconv.Itoa(int(defaultPort))
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return HostPort{}, fmt.Errorf("invalid port %q in %q", port, s)
+	}
+
+	// Only IP literals can be checked against the options,
+	// host names are only checked for their syntax.
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := addrOptions(opts).check(addr); err != nil {
+			return HostPort{}, err
+		}
+	} else if host != "" && !isHostname(host) {
+		return HostPort{}, fmt.Errorf("invalid host %q in %q", host, s)
+	}
+
+	return HostPort{Host: host, Port: uint16(p)}, nil
+}
+
+// isHostname is true when s is a syntactically valid host name:
+// dot separated labels of letters, digits, hyphens and
+// underscores, not starting or ending with a hyphen.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// HostPort flag getter. Deals with parsing host:port inputs,
+// filling in a default port when it is missing.
+// This type implements the flag.Getter interface.
+type HostPortFlag struct {
+	ptr         *HostPort
+	defaultPort uint16
+	opts        []AddrOption
+}
+
+func NewHostPortFlag(v *HostPort, defaultPort uint16, opts ...AddrOption) *HostPortFlag {
+	return &HostPortFlag{
+		ptr:         v,
+		defaultPort: defaultPort,
+		opts:        opts,
+	}
+}
+
+func (self HostPortFlag) String() string {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return self.ptr.String()
+	}
+}
+
+func (self *HostPortFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = &HostPort{}
+	}
+
+	if v, err := ParseHostPort(s, self.defaultPort, self.opts...); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self HostPortFlag) Get() interface{} {
+	if self.ptr == nil {
+		return HostPort{}
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *HostPortFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = HostPort{}
+	}
+}
+
+// HostPortSliceFlagTarget is a host:port Target for a
+// SliceFlag.
+type HostPortSliceFlagTarget struct {
+	Target      *[]HostPort
+	DefaultPort uint16
+	Options     []AddrOption
+}
+
+func (self *HostPortSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]HostPort{}
+	}
+}
+
+func (self *HostPortSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *HostPortSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	if v, err := ParseHostPort(item, self.DefaultPort, self.Options...); err != nil {
+		return nil, err
+	} else {
+		*self.Target = append(*self.Target, v)
+	}
+	return self, nil
+}
+
+func (self *HostPortSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	pieces := make([]string, len(*self.Target))
+	for k, v := range *self.Target {
+		pieces[k] = v.String()
+	}
+	return strings.Join(pieces, del)
+}
+
+func (self *HostPortSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
+
+// MAC flag getter. Deals with parsing hardware address inputs.
+// This type implements the flag.Getter interface.
+type MACFlag struct {
+	ptr *net.HardwareAddr
+}
+
+func NewMACFlag(v *net.HardwareAddr) *MACFlag {
+	return &MACFlag{
+		ptr: v,
+	}
+}
+
+func (self MACFlag) String() string {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return self.ptr.String()
+	}
+}
+
+func (self *MACFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = &net.HardwareAddr{}
+	}
+
+	if v, err := net.ParseMAC(s); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self MACFlag) Get() interface{} {
+	if self.ptr == nil {
+		return net.HardwareAddr(nil)
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *MACFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = nil
+	}
+}
+
+// MACSliceFlagTarget is a hardware address Target for a
+// SliceFlag.
+type MACSliceFlagTarget struct {
+	Target *[]net.HardwareAddr
+}
+
+func (self *MACSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]net.HardwareAddr{}
+	}
+}
+
+func (self *MACSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *MACSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	if v, err := net.ParseMAC(item); err != nil {
+		return nil, err
+	} else {
+		*self.Target = append(*self.Target, v)
+	}
+	return self, nil
+}
+
+func (self *MACSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	pieces := make([]string, len(*self.Target))
+	for k, v := range *self.Target {
+		pieces[k] = v.String()
+	}
+	return strings.Join(pieces, del)
+}
+
+func (self *MACSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
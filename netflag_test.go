@@ -0,0 +1,179 @@
+package glarg
+
+import (
+	"flag"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestIPFlag(t *testing.T) {
+	// a default IPFlag is empty.
+	flag1 := &IPFlag{}
+	if flag1.String() != "" {
+		t.Errorf("Error. Expected an empty string. Received: %s.", flag1.String())
+	}
+	if tmp, ok := flag1.Get().(netip.Addr); !ok {
+		t.Errorf("Error. Expected a netip.Addr. Got something else.")
+	} else if tmp.IsValid() {
+		t.Errorf("Error. Expected an invalid address. Received: %s", tmp)
+	}
+
+	var addr netip.Addr
+	flag2 := NewIPFlag(&addr)
+	for _, v := range []string{"10.0.0.1", "::1", "fe80::1"} {
+		if err := flag2.Set(v); err != nil {
+			t.Errorf("Error. Expected set to work. Received: %s", err)
+		} else if addr.String() != v {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, addr)
+		}
+	}
+	if err := flag2.Set("10.0.0.256"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	cases := []struct {
+		opts  []AddrOption
+		input string
+		ok    bool
+	}{
+		{[]AddrOption{IPv4Only}, "10.0.0.1", true},
+		{[]AddrOption{IPv4Only}, "::ffff:10.0.0.1", true},
+		{[]AddrOption{IPv4Only}, "2001:db8::1", false},
+		{[]AddrOption{IPv6Only}, "2001:db8::1", true},
+		{[]AddrOption{IPv6Only}, "10.0.0.1", false},
+		{[]AddrOption{NoLoopback}, "127.0.0.1", false},
+		{[]AddrOption{NoLoopback}, "::1", false},
+		{[]AddrOption{IPv4Only, NoLoopback}, "192.168.1.1", true},
+	}
+	for _, v := range cases {
+		err := NewIPFlag(&addr, v.opts...).Set(v.input)
+		if v.ok && err != nil {
+			t.Errorf("Error. Expected %s to be accepted. Received: %s", v.input, err)
+		} else if !v.ok && err == nil {
+			t.Errorf("Error. Expected %s to be rejected.", v.input)
+		}
+	}
+
+	var addrs []netip.Addr
+	fs := flag.NewFlagSet("IPFlag", flag.ContinueOnError)
+	fs.Var(NewSliceFlag(&IPSliceFlagTarget{Target: &addrs, Options: []AddrOption{IPv4Only}}, ""), "ips", "IPs.")
+	if err := fs.Parse([]string{"-ips", "10.0.0.1,10.0.0.2"}); err != nil {
+		t.Errorf("Error. Expected parse to work. Received: %s", err)
+	} else if len(addrs) != 2 {
+		t.Errorf("Error. Expected: 2. Received: %d.", len(addrs))
+	}
+	if err := fs.Parse([]string{"-ips", "10.0.0.1,::1"}); err == nil {
+		t.Errorf("Error. Expected parse to fail.")
+	}
+}
+
+func TestPrefixFlag(t *testing.T) {
+	var prefix netip.Prefix
+	flag1 := NewPrefixFlag(&prefix, IPv4Only)
+	if err := flag1.Set("10.0.0.0/8"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if flag1.String() != "10.0.0.0/8" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "10.0.0.0/8", flag1.String())
+	}
+	if err := flag1.Set("2001:db8::/32"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+	if err := flag1.Set("10.0.0.0"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	target := &PrefixSliceFlagTarget{}
+	flag2 := NewSliceFlag(target, "")
+	if err := flag2.Set("10.0.0.0/8,2001:db8::/32"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if flag2.String() != "10.0.0.0/8,2001:db8::/32" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "10.0.0.0/8,2001:db8::/32", flag2.String())
+	}
+}
+
+func TestHostPortFlag(t *testing.T) {
+	cases := []struct {
+		input    string
+		port     uint16
+		expected string
+		ok       bool
+	}{
+		{"example.com:80", 0, "example.com:80", true},
+		{"example.com", 0, "", false},
+		{"example.com", 443, "example.com:443", true},
+		{"example.com:", 443, "example.com:443", true},
+		{":8080", 0, ":8080", true},
+		{"10.0.0.1", 443, "10.0.0.1:443", true},
+		{"::1", 443, "[::1]:443", true},
+		{"[::1]", 443, "[::1]:443", true},
+		{"[::1]:22", 443, "[::1]:22", true},
+		{"[foo", 443, "", false},
+		{"::1]", 443, "", false},
+		{"[example.com]", 443, "", false},
+		{"[example.com]:80", 0, "", false},
+		{"[10.0.0.1]", 443, "", false},
+		{"[fe80::1%eth0]:22", 0, "[fe80::1%eth0]:22", true},
+		{"example.com:http", 0, "", false},
+		{"example.com:70000", 0, "", false},
+		{"a:b:c", 443, "", false},
+		{"", 443, "", false},
+		{":", 443, "", false},
+		{":", 0, "", false},
+		{"a b:80", 0, "", false},
+		{"a b", 443, "", false},
+		{"-bad.example:80", 0, "", false},
+		{"my_host.local:80", 0, "my_host.local:80", true},
+	}
+	for _, v := range cases {
+		var hp HostPort
+		err := NewHostPortFlag(&hp, v.port).Set(v.input)
+		if v.ok && err != nil {
+			t.Errorf("Error. Expected %s to be accepted. Received: %s", v.input, err)
+		} else if !v.ok && err == nil {
+			t.Errorf("Error. Expected %s to be rejected.", v.input)
+		} else if hp.String() != v.expected {
+			t.Errorf("Error. Expected: %s. Received: %s.", v.expected, hp)
+		}
+	}
+
+	var hp HostPort
+	if err := NewHostPortFlag(&hp, 22, NoLoopback).Set("127.0.0.1"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	var hps []HostPort
+	flag1 := NewSliceFlag(&HostPortSliceFlagTarget{Target: &hps, DefaultPort: 22}, "")
+	if err := flag1.Set("a,b:2222"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if flag1.String() != "a:22,b:2222" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "a:22,b:2222", flag1.String())
+	}
+}
+
+func TestMACFlag(t *testing.T) {
+	var mac net.HardwareAddr
+	flag1 := NewMACFlag(&mac)
+	if err := flag1.Set("00:00:5e:00:53:01"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if flag1.String() != "00:00:5e:00:53:01" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "00:00:5e:00:53:01", flag1.String())
+	}
+	if err := flag1.Set("not a mac"); err == nil {
+		t.Errorf("Error. Expected set to fail.")
+	}
+
+	flag2 := NewSliceFlag(&MACSliceFlagTarget{}, ";")
+	if err := flag2.Set("00:00:5e:00:53:01;00-00-5e-00-53-02"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if tmp, ok := flag2.Get().([]net.HardwareAddr); !ok {
+		t.Errorf("Error. Expected a []net.HardwareAddr. Got something else.")
+	} else if len(tmp) != 2 || tmp[1].String() != "00:00:5e:00:53:02" {
+		t.Errorf("Error. Unexpected addresses. Received: %v", tmp)
+	}
+}
@@ -0,0 +1,520 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	OUTPUT_TABLE    = "table"
+	OUTPUT_JSON     = "json"
+	OUTPUT_JSONL    = "jsonl"
+	OUTPUT_YAML     = "yaml"
+	OUTPUT_CSV      = "csv"
+	OUTPUT_TEMPLATE = "template"
+)
+
+// The space between the columns of a table.
+const TABLE_GAP = "   "
+
+var outputChoices = []EnumChoice{
+	{OUTPUT_TABLE, "Aligned columns."},
+	{OUTPUT_JSON, "One indented JSON document."},
+	{OUTPUT_JSONL, "One JSON document per line and result."},
+	{OUTPUT_YAML, "A YAML document."},
+	{OUTPUT_CSV, "Comma separated values with a header."},
+	{OUTPUT_TEMPLATE, "The -template executed for every result."},
+}
+
+// Output renders the results of a command in the format picked
+// with the -output flag. Commands create one in SetupSubcommand,
+// add its flags, and pass their results to Render in Execute.
+//
+// The results are a struct, a map, or a slice of them, and are
+// marshaled with encoding/json, so the json tags name the columns
+// and the fields. A slice is one row, JSON line or template run per
+// element.
+type Output struct {
+	format    string
+	fallback  string
+	template  string
+	columns   []string
+	noHeaders bool
+}
+
+// NewOutput returns an Output rendering in format when -output is
+// not given, or when only -template is given, in the template.
+func NewOutput(format string) *Output {
+	return &Output{fallback: format}
+}
+
+// AddFlags adds -output, -columns, -no-headers and -template to fs.
+func (self *Output) AddFlags(fs *flag.FlagSet) {
+	format := NewEnumFlagWithChoices(&self.format, outputChoices).IgnoreCase()
+	fs.Var(format, "output", format.Usage("The format of the results"))
+	fs.Var(NewSliceFlag(&StringSliceFlagTarget{&self.columns}, ""), "columns", "The columns of the table or csv output.")
+	fs.BoolVar(&self.noHeaders, "no-headers", false, "Leave the header out of the table or csv output.")
+	fs.StringVar(&self.template, "template", "", "The text/template for the template output.")
+}
+
+// Format returns the format Render uses.
+func (self *Output) Format() string {
+	switch {
+	case self.format != "":
+		return self.format
+	case self.template != "":
+		return OUTPUT_TEMPLATE
+	}
+	return self.fallback
+}
+
+// Render writes v to the standard output of the Environment. The
+// table is cut to the width of the terminal when the standard
+// output is one.
+func (self *Output) Render(ctx context.Context, v interface{}) error {
+	env := Env(ctx)
+	w := env.Stdout
+
+	switch self.Format() {
+	case OUTPUT_TEMPLATE:
+		return self.renderTemplate(w, v)
+	case OUTPUT_JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OUTPUT_JSONL:
+		enc := json.NewEncoder(w)
+		for _, v := range outputItems(v) {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	node, err := outputNode(v)
+	if err != nil {
+		return err
+	}
+	switch self.Format() {
+	case OUTPUT_YAML:
+		_, err := io.WriteString(w, strings.Join(yamlLines(node), "\n")+"\n")
+		return err
+	case OUTPUT_CSV:
+		header, rows, err := self.rows(node)
+		if err != nil {
+			return err
+		}
+		cw := csv.NewWriter(w)
+		if !self.noHeaders {
+			cw.Write(header)
+		}
+		cw.WriteAll(rows)
+		return cw.Error()
+	case OUTPUT_TABLE:
+		header, rows, err := self.rows(node)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		var styler Styler
+		if !self.noHeaders {
+			for i, v := range header {
+				header[i] = strings.ToUpper(v)
+			}
+			rows = append([][]string{header}, rows...)
+			styler = env.Styler(w)
+		}
+		return writeTable(w, rows, terminalWidth(env), styler)
+	}
+	return fmt.Errorf("unknown output format %q", self.Format())
+}
+
+func (self *Output) renderTemplate(w io.Writer, v interface{}) error {
+	if self.template == "" {
+		return fmt.Errorf("the template output needs a -template")
+	}
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"join": strings.Join,
+	}).Parse(self.template)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range outputItems(v) {
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, v); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteByte('\n')
+		}
+		if _, err := w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputItems returns the elements of v when it is a slice or an
+// array, and v alone otherwise.
+func outputItems(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Slice {
+		rv = rv.Elem()
+	}
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return []interface{}{v}
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// outputField is one field of an outputObject. The objects keep
+// the fields in the order they were marshaled, which is the order
+// of the struct fields.
+type outputField struct {
+	key   string
+	value interface{}
+}
+
+type outputObject []outputField
+
+// outputNode marshals v to JSON and reads it back as outputObject,
+// []interface{}, string, json.Number, bool and nil values.
+func outputNode(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeOutputNode(dec)
+}
+
+func decodeOutputNode(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := outputObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOutputNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, outputField{key.(string), value})
+		}
+		_, err := dec.Token()
+		return object, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOutputNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return token, nil
+}
+
+// rows returns the header and the cells of the table or csv output.
+// The columns are the keys of the objects in the order they are
+// first seen, or the ones picked with -columns. Results which are
+// not objects are a single VALUE column.
+func (self *Output) rows(node interface{}) ([]string, [][]string, error) {
+	items, ok := node.([]interface{})
+	if !ok {
+		items = []interface{}{node}
+	}
+
+	header := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		object, ok := item.(outputObject)
+		if !ok {
+			object = outputObject{{"value", item}}
+		}
+		for _, v := range object {
+			if !seen[v.key] {
+				seen[v.key] = true
+				header = append(header, v.key)
+			}
+		}
+	}
+
+	if len(self.columns) > 0 {
+		picked := []string{}
+		for _, c := range self.columns {
+			found := false
+			for _, v := range header {
+				if strings.EqualFold(v, c) {
+					picked = append(picked, v)
+					found = true
+					break
+				}
+			}
+			if !found && len(items) > 0 {
+				return nil, nil, fmt.Errorf("unknown column %q, must be one of: %s", c, strings.Join(header, ", "))
+			}
+		}
+		header = picked
+	}
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		object, ok := item.(outputObject)
+		if !ok {
+			object = outputObject{{"value", item}}
+		}
+		row := make([]string, len(header))
+		for j, key := range header {
+			for _, v := range object {
+				if v.key == key {
+					row[j] = cellText(v.value)
+				}
+			}
+		}
+		rows[i] = row
+	}
+	return header, rows, nil
+}
+
+// cellText returns the text of a scalar, and the compact JSON of
+// an object or a list.
+func cellText(node interface{}) string {
+	switch v := node.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	b, _ := json.Marshal(jsonNode(node))
+	return string(b)
+}
+
+// jsonNode turns the outputObjects back into values encoding/json
+// marshals in the same order.
+func jsonNode(node interface{}) interface{} {
+	switch v := node.(type) {
+	case outputObject:
+		var b bytes.Buffer
+		b.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(f.key)
+			value, _ := json.Marshal(jsonNode(f.value))
+			b.Write(key)
+			b.WriteByte(':')
+			b.Write(value)
+		}
+		b.WriteByte('}')
+		return json.RawMessage(b.Bytes())
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = jsonNode(item)
+		}
+		return list
+	}
+	return node
+}
+
+// terminalWidth returns the width of the standard output when it
+// is a terminal, and 0 otherwise.
+func terminalWidth(env *Environment) int {
+	f, ok := env.Stdout.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0
+	}
+	if width, _, err := term.GetSize(int(f.Fd())); err == nil {
+		return width
+	}
+	if width, err := strconv.Atoi(env.Getenv("COLUMNS")); err == nil {
+		return width
+	}
+	return 0
+}
+
+// tableEscaper escapes the characters which would break the lines
+// or the columns of a table.
+var tableEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// writeTable aligns the rows in columns. When width is not 0 the
+// widest columns are cut, down to a few characters, until the rows
+// fit in it. The first row is styled as the header by styler.
+func writeTable(w io.Writer, rows [][]string, width int, styler Styler) error {
+	escaped := make([][]string, len(rows))
+	for r, row := range rows {
+		escaped[r] = make([]string, len(row))
+		for i, v := range row {
+			escaped[r][i] = tableEscaper.Replace(v)
+		}
+	}
+	rows = escaped
+
+	widths := []int{}
+	for _, row := range rows {
+		for i, v := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(v); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if width > 0 {
+		for {
+			total := len(TABLE_GAP) * (len(widths) - 1)
+			widest := 0
+			for i, v := range widths {
+				total += v
+				if v > widths[widest] {
+					widest = i
+				}
+			}
+			if total <= width || widths[widest] <= 5 {
+				break
+			}
+			widths[widest] -= min(total-width, widths[widest]-5)
+		}
+	}
+
+	var b strings.Builder
+	for r, row := range rows {
+		var line strings.Builder
+		for i, v := range row {
+			v = truncate(v, widths[i])
+			if r == 0 {
+				line.WriteString(styler.Style(STYLE_HEADER, v))
+			} else {
+				line.WriteString(v)
+			}
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(v)))
+				line.WriteString(TABLE_GAP)
+			}
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// yamlLines returns the YAML of node, one line per element.
+func yamlLines(node interface{}) []string {
+	switch v := node.(type) {
+	case outputObject:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		lines := []string{}
+		for _, f := range v {
+			child := yamlLines(f.value)
+			if yamlInline(f.value) {
+				lines = append(lines, yamlString(f.key)+": "+child[0])
+				continue
+			}
+			lines = append(lines, yamlString(f.key)+":")
+			for _, line := range child {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		lines := []string{}
+		for _, item := range v {
+			for i, line := range yamlLines(item) {
+				if i == 0 {
+					lines = append(lines, "- "+line)
+				} else {
+					lines = append(lines, "  "+line)
+				}
+			}
+		}
+		return lines
+	case nil:
+		return []string{"null"}
+	case string:
+		return []string{yamlString(v)}
+	}
+	return []string{cellText(node)}
+}
+
+func yamlInline(node interface{}) bool {
+	switch v := node.(type) {
+	case outputObject:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@ -]*$`)
+
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true,
+	"off": true, "y": true, "n": true, "null": true,
+}
+
+// yamlString quotes s unless it can only be read back as the same
+// string.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") && !yamlReserved[strings.ToLower(s)] {
+		return s
+	}
+	return strconv.Quote(s)
+}
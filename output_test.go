@@ -0,0 +1,169 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strings"
+	"testing"
+)
+
+type outputTestItem struct {
+	Name   string            `json:"name"`
+	Size   int               `json:"size"`
+	Tags   []string          `json:"tags,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type outputTestCommand struct {
+	SubcommandNoOp
+	output *Output
+	err    error
+}
+
+func (self *outputTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ContinueOnError)
+	self.output = NewOutput(OUTPUT_TABLE)
+	self.output.AddFlags(self.flagSet)
+	return self
+}
+
+func (self *outputTestCommand) Execute(ctx context.Context) int {
+	self.err = self.output.Render(ctx, []outputTestItem{
+		{Name: "alpha", Size: 1, Tags: []string{"a", "b"}},
+		{Name: "beta two", Size: 200, Labels: map[string]string{"on": "yes"}},
+	})
+	if self.err != nil {
+		return 1
+	}
+	return 0
+}
+
+func TestOutputRender(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{}, "" +
+			"NAME       SIZE   TAGS        LABELS\n" +
+			"alpha      1      [\"a\",\"b\"]\n" +
+			"beta two   200                {\"on\":\"yes\"}\n"},
+		{[]string{"-columns", "SIZE,name", "-no-headers"}, "" +
+			"1     alpha\n" +
+			"200   beta two\n"},
+		{[]string{"-output", "csv", "-columns", "name,size"}, "" +
+			"name,size\n" +
+			"alpha,1\n" +
+			"beta two,200\n"},
+		{[]string{"-output", "jsonl", "-columns", "name"}, "" +
+			"{\"name\":\"alpha\",\"size\":1,\"tags\":[\"a\",\"b\"]}\n" +
+			"{\"name\":\"beta two\",\"size\":200,\"labels\":{\"on\":\"yes\"}}\n"},
+		{[]string{"-output", "yaml"}, "" +
+			"- name: alpha\n" +
+			"  size: 1\n" +
+			"  tags:\n" +
+			"    - a\n" +
+			"    - b\n" +
+			"- name: beta two\n" +
+			"  size: 200\n" +
+			"  labels:\n" +
+			"    \"on\": \"yes\"\n"},
+		{[]string{"-template", "{{.Name}}={{.Size}}"}, "" +
+			"alpha=1\n" +
+			"beta two=200\n"},
+		{[]string{"-output", "JSON"}, "[\n" +
+			"  {\n    \"name\": \"alpha\",\n    \"size\": 1,\n    \"tags\": [\n      \"a\",\n      \"b\"\n    ]\n  },\n" +
+			"  {\n    \"name\": \"beta two\",\n    \"size\": 200,\n    \"labels\": {\n      \"on\": \"yes\"\n    }\n  }\n]\n"},
+	}
+	for _, v := range cases {
+		var stdout bytes.Buffer
+		cmd := &outputTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}
+		root := &Subcommands{Name: "tool", Children: []Subcommand{cmd}}
+		rc := Invoke(context.Background(), root, append([]string{"tool", "list"}, v.args...), WithEnvironment(&Environment{Stdout: &stdout}))
+		if rc != 0 {
+			t.Errorf("Error. Args: %v. Expected: 0. Received: %d. %v", v.args, rc, cmd.err)
+		}
+		if stdout.String() != v.expected {
+			t.Errorf("Error. Args: %v. Expected:\n%s\nReceived:\n%s", v.args, v.expected, stdout.String())
+		}
+	}
+}
+
+func TestOutputErrors(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-columns", "name,owner"}, `unknown column "owner", must be one of: name, size, tags, labels`},
+		{[]string{"-output", "template"}, "the template output needs a -template"},
+		{[]string{"-template", "{{.Owner}}"}, "can't evaluate field Owner"},
+	}
+	for _, v := range cases {
+		var stdout bytes.Buffer
+		cmd := &outputTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}
+		root := &Subcommands{Name: "tool", Children: []Subcommand{cmd}}
+		Invoke(context.Background(), root, append([]string{"tool", "list"}, v.args...), WithEnvironment(&Environment{Stdout: &stdout}))
+		if cmd.err == nil || !strings.Contains(cmd.err.Error(), v.expected) {
+			t.Errorf("Error. Args: %v. Expected: %s. Received: %v.", v.args, v.expected, cmd.err)
+		}
+	}
+}
+
+func TestWriteTableWidth(t *testing.T) {
+	rows := [][]string{
+		{"ID", "DESCRIPTION"},
+		{"1", "A rather long description of the first item"},
+	}
+	var b bytes.Buffer
+	writeTable(&b, rows, 24, Styler{})
+	expected := "" +
+		"ID   DESCRIPTION\n" +
+		"1    A rather long desc…\n"
+	if b.String() != expected {
+		t.Errorf("Error. Expected:\n%s\nReceived:\n%s", expected, b.String())
+	}
+}
+
+func TestOutputScalars(t *testing.T) {
+	var stdout bytes.Buffer
+	ctx := context.WithValue(context.Background(), environmentKey{}, (&Environment{Stdout: &stdout}).withDefaults())
+	NewOutput(OUTPUT_TABLE).Render(ctx, []string{"a", "b"})
+	if stdout.String() != "VALUE\na\nb\n" {
+		t.Errorf("Error. Expected: VALUE a b. Received: %q.", stdout.String())
+	}
+
+	stdout.Reset()
+	NewOutput(OUTPUT_YAML).Render(ctx, map[string]interface{}{"count": 2, "empty": []string{}, "version": "1.0", "nothing": nil})
+	expected := "count: 2\nempty: []\nnothing: null\nversion: \"1.0\"\n"
+	if stdout.String() != expected {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, stdout.String())
+	}
+}
+
+func TestOutputHelp(t *testing.T) {
+	var stderr bytes.Buffer
+	root := &Subcommands{Name: "tool", Children: []Subcommand{&outputTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}}}
+	Invoke(context.Background(), root, []string{"tool", "list", "-h"}, WithEnvironment(&Environment{Stderr: &stderr}))
+	for _, v := range []string{"The format of the results. One of:", "table     Aligned columns.", "template  The -template executed for every result."} {
+		if !strings.Contains(stderr.String(), v) {
+			t.Errorf("Error. Expected: %s. Received: %s.", v, stderr.String())
+		}
+	}
+}
+
+func TestWriteTableEscapes(t *testing.T) {
+	rows := [][]string{
+		{"ID", "NOTE"},
+		{"1", "x\ny"},
+		{"2", "a\tb\r"},
+	}
+	var b bytes.Buffer
+	writeTable(&b, rows, 0, Styler{})
+	expected := "" +
+		"ID   NOTE\n" +
+		"1    x\\ny\n" +
+		"2    a\\tb\\r\n"
+	if b.String() != expected {
+		t.Errorf("Error. Expected:\n%s\nReceived:\n%s", expected, b.String())
+	}
+}
@@ -0,0 +1,332 @@
+package glarg
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PathOption controls how the Path flags expand and check
+// their values. Options can be combined.
+type PathOption int
+
+const (
+	// The path must exist.
+	PathMustExist PathOption = 1 << iota
+	// The path must not exist yet.
+	PathMustNotExist
+	// The path must exist and be a regular file.
+	PathFile
+	// The path must exist and be a directory.
+	PathDir
+	// The path must be readable.
+	PathReadable
+	// The path must be writable. A path that does not exist
+	// yet is writable when its parent directory is.
+	PathWritable
+	// Expand a leading ~ and environment variables.
+	PathExpand
+	// Resolve the path to an absolute path.
+	PathAbsolute
+	// Expand glob patterns. Only used by the PathSliceFlagTarget.
+	PathGlob
+)
+
+// STDIO_PATH is the path meaning stdin or stdout. It is
+// never expanded or checked.
+const STDIO_PATH = "-"
+
+func pathOptions(opts []PathOption) PathOption {
+	var all PathOption
+	for _, v := range opts {
+		all |= v
+	}
+	return all
+}
+
+// expand expands s with the variables and the home directory of
+// env.
+func (self PathOption) expand(env *Environment, s string) (string, error) {
+	if s == STDIO_PATH {
+		return s, nil
+	}
+
+	if self&PathExpand != 0 {
+		s = os.Expand(s, env.Getenv)
+		if s == "~" || strings.HasPrefix(s, "~/") || strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+			home, err := env.homeDir()
+			if err != nil {
+				return "", err
+			}
+			s = filepath.Join(home, s[1:])
+		}
+	}
+
+	if self&PathAbsolute != 0 {
+		abs, err := filepath.Abs(s)
+		if err != nil {
+			return "", err
+		}
+		s = abs
+	}
+	return s, nil
+}
+
+func (self PathOption) check(s string) error {
+	if s == STDIO_PATH {
+		return nil
+	}
+
+	info, err := os.Stat(s)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !exists && self&(PathMustExist|PathFile|PathDir|PathReadable) != 0 {
+		return fmt.Errorf("%s does not exist", s)
+	}
+	if exists && self&PathMustNotExist != 0 {
+		return fmt.Errorf("%s already exists", s)
+	}
+	if exists && self&PathFile != 0 && !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a file", s)
+	}
+	if exists && self&PathDir != 0 && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", s)
+	}
+
+	if self&PathReadable != 0 {
+		if f, err := os.Open(s); err != nil {
+			return fmt.Errorf("%s is not readable", s)
+		} else {
+			f.Close()
+		}
+	}
+
+	if self&PathWritable != 0 && !writable(s, info, exists) {
+		return fmt.Errorf("%s is not writable", s)
+	}
+	return nil
+}
+
+// writable is true when the user can write to s, or create it when
+// it does not exist yet. It asks the system, see canWrite, so that
+// nothing is written to check.
+func writable(s string, info os.FileInfo, exists bool) bool {
+	if !exists {
+		dir := filepath.Dir(s)
+		var err error
+		if info, err = os.Stat(dir); err != nil || !info.IsDir() {
+			return false
+		}
+		s = dir
+	}
+	return canWrite(s, info)
+}
+
+// Path flag getter. Deals with expanding and checking file
+// system paths. The checks run in Validate, after all of the
+// flags are parsed, so that Subcommands can report them together.
+// This type implements the flag.Getter and Validator interfaces.
+type PathFlag struct {
+	ptr  *string
+	opts PathOption
+	env  *Environment
+}
+
+func NewPathFlag(v *string, opts ...PathOption) *PathFlag {
+	return &PathFlag{
+		ptr:  v,
+		opts: pathOptions(opts),
+	}
+}
+
+func (self PathFlag) String() string {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *PathFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = new(string)
+	}
+
+	if v, err := self.opts.expand(self.environment(), s); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return nil
+}
+
+func (self PathFlag) Get() interface{} {
+	if self.ptr == nil {
+		return ""
+	} else {
+		return *self.ptr
+	}
+}
+
+func (self *PathFlag) Reset() {
+	if self.ptr != nil {
+		*self.ptr = ""
+	}
+}
+
+// snapshot returns the path as it is, which Set would expand. See
+// snapshotter.
+func (self *PathFlag) snapshot() interface{} {
+	return self.String()
+}
+
+func (self *PathFlag) restore(v interface{}) {
+	if self.ptr != nil {
+		*self.ptr = v.(string)
+	}
+}
+
+func (self PathFlag) Validate() error {
+	if self.ptr == nil || *self.ptr == "" {
+		return nil
+	}
+	return self.opts.check(*self.ptr)
+}
+
+// validateDefault expands the default the way Set expands the
+// values given, then checks it. See defaultValidator.
+func (self *PathFlag) validateDefault() error {
+	if self.ptr == nil || *self.ptr == "" {
+		return nil
+	}
+	if v, err := self.opts.expand(self.environment(), *self.ptr); err != nil {
+		return err
+	} else {
+		*self.ptr = v
+	}
+	return self.Validate()
+}
+
+func (self *PathFlag) useEnvironment(env *Environment) {
+	self.env = env
+}
+
+func (self PathFlag) environment() *Environment {
+	if self.env == nil {
+		return DefaultEnvironment()
+	}
+	return self.env
+}
+
+// IsStdio is true when the path is "-".
+func (self PathFlag) IsStdio() bool {
+	return self.ptr != nil && *self.ptr == STDIO_PATH
+}
+
+// Open opens the path for reading. "-" is the stdin of the
+// Environment, which is not closed when the returned reader is.
+func (self PathFlag) Open() (io.ReadCloser, error) {
+	if self.IsStdio() {
+		return io.NopCloser(self.environment().Stdin), nil
+	}
+	return os.Open(self.String())
+}
+
+// Create creates or truncates the path for writing. "-" is the
+// stdout of the Environment, which is not closed when the returned
+// writer is.
+func (self PathFlag) Create() (io.WriteCloser, error) {
+	if self.IsStdio() {
+		return nopWriteCloser{self.environment().Stdout}, nil
+	}
+	return os.Create(self.String())
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// PathSliceFlagTarget is a Path Target for a
+// SliceFlag. With PathGlob, patterns are expanded to the
+// matching paths. Patterns without matches are kept as is.
+type PathSliceFlagTarget struct {
+	Target  *[]string
+	Options []PathOption
+	env     *Environment
+}
+
+func (self *PathSliceFlagTarget) makeSafe() {
+	if self.Target == nil {
+		self.Target = &[]string{}
+	}
+}
+
+func (self *PathSliceFlagTarget) Clear() {
+	self.makeSafe()
+	*self.Target = (*self.Target)[:0]
+}
+
+func (self *PathSliceFlagTarget) Append(item string) (SliceFlagTarget, error) {
+	self.makeSafe()
+	opts := pathOptions(self.Options)
+	env := self.env
+	if env == nil {
+		env = DefaultEnvironment()
+	}
+	v, err := opts.expand(env, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts&PathGlob != 0 && v != STDIO_PATH {
+		matches, err := filepath.Glob(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", item, err)
+		}
+		if len(matches) > 0 {
+			*self.Target = append(*self.Target, matches...)
+			return self, nil
+		}
+	}
+
+	*self.Target = append(*self.Target, v)
+	return self, nil
+}
+
+func (self *PathSliceFlagTarget) useEnvironment(env *Environment) {
+	self.env = env
+}
+
+func (self *PathSliceFlagTarget) Join(del string) string {
+	self.makeSafe()
+	return strings.Join(*self.Target, del)
+}
+
+func (self *PathSliceFlagTarget) Get() interface{} {
+	self.makeSafe()
+	return *self.Target
+}
+
+func (self *PathSliceFlagTarget) Validate() error {
+	self.makeSafe()
+	opts := pathOptions(self.Options)
+	errs := []string{}
+	for _, v := range *self.Target {
+		if err := opts.check(v); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package glarg
+
+import (
+	"os"
+)
+
+// canWrite checks the permission bits of s, which is all the
+// systems without access(2) have. On Windows, a read-only file has
+// no write bits.
+func canWrite(s string, info os.FileInfo) bool {
+	return info.Mode().Perm()&0200 != 0
+}
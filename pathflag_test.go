@@ -0,0 +1,251 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathFlag(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("key: value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	cases := []struct {
+		opts  []PathOption
+		input string
+		ok    bool
+	}{
+		{nil, missing, true},
+		{[]PathOption{PathMustExist}, file, true},
+		{[]PathOption{PathMustExist}, missing, false},
+		{[]PathOption{PathMustNotExist}, missing, true},
+		{[]PathOption{PathMustNotExist}, file, false},
+		{[]PathOption{PathFile}, file, true},
+		{[]PathOption{PathFile}, dir, false},
+		{[]PathOption{PathDir}, dir, true},
+		{[]PathOption{PathDir}, file, false},
+		{[]PathOption{PathReadable}, file, true},
+		{[]PathOption{PathWritable}, file, true},
+		{[]PathOption{PathWritable}, missing, true},
+		{[]PathOption{PathWritable}, filepath.Join(missing, "file"), false},
+		{[]PathOption{PathFile}, STDIO_PATH, true},
+	}
+	for _, v := range cases {
+		var path string
+		flag1 := NewPathFlag(&path, v.opts...)
+		if err := flag1.Set(v.input); err != nil {
+			t.Errorf("Error. Expected set to work. Received: %s", err)
+		}
+		err := flag1.Validate()
+		if v.ok && err != nil {
+			t.Errorf("Error. Expected %s to be valid. Received: %s", v.input, err)
+		} else if !v.ok && err == nil {
+			t.Errorf("Error. Expected %s to be invalid.", v.input)
+		}
+	}
+
+	// Checking a directory writes nothing in it.
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Error. Expected only config.yaml. Received: %v.", entries)
+	}
+	if os.Geteuid() > 0 {
+		locked := filepath.Join(dir, "locked")
+		os.Mkdir(locked, 0500)
+		var path string
+		flag1 := NewPathFlag(&path, PathWritable)
+		flag1.Set(filepath.Join(locked, "file"))
+		if err := flag1.Validate(); err == nil {
+			t.Errorf("Error. Expected a read-only directory to be invalid.")
+		}
+		os.Remove(locked)
+	}
+
+	// Expansion of ~ and environment variables.
+	t.Setenv("HOME", dir)
+	t.Setenv("GLARG_TEST_DIR", dir)
+	var path string
+	flag2 := NewPathFlag(&path, PathExpand)
+	for _, v := range []string{"~/config.yaml", "$GLARG_TEST_DIR/config.yaml"} {
+		if err := flag2.Set(v); err != nil {
+			t.Errorf("Error. Expected set to work. Received: %s", err)
+		} else if path != file {
+			t.Errorf("Error. Expected: %s. Received: %s.", file, path)
+		}
+	}
+
+	flag3 := NewPathFlag(&path, PathAbsolute)
+	if err := flag3.Set("relative"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	} else if !filepath.IsAbs(path) {
+		t.Errorf("Error. Expected an absolute path. Received: %s.", path)
+	}
+
+	// Lazily opening the file.
+	flag4 := NewPathFlag(&path)
+	flag4.Set(file)
+	if r, err := flag4.Open(); err != nil {
+		t.Errorf("Error. Expected open to work. Received: %s", err)
+	} else {
+		r.Close()
+	}
+	flag4.Set(STDIO_PATH)
+	if !flag4.IsStdio() {
+		t.Errorf("Error. Expected - to be stdio.")
+	}
+	if w, err := flag4.Create(); err != nil {
+		t.Errorf("Error. Expected create to work. Received: %s", err)
+	} else if err := w.Close(); err != nil {
+		t.Errorf("Error. Expected close to work. Received: %s", err)
+	}
+}
+
+func TestPathSliceFlagTarget(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"a.log", "b.log", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, v), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var paths []string
+	flag1 := NewSliceFlag(&PathSliceFlagTarget{Target: &paths, Options: []PathOption{PathGlob, PathFile}}, "")
+	if err := flag1.Set(filepath.Join(dir, "*.log") + "," + filepath.Join(dir, "c.txt")); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if len(paths) != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", len(paths))
+	}
+	if err := flag1.Validate(); err != nil {
+		t.Errorf("Error. Expected validate to work. Received: %s", err)
+	}
+
+	// No matches keeps the pattern, which then fails validation.
+	if err := flag1.Set(filepath.Join(dir, "*.csv")); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+	if err := flag1.Validate(); err == nil {
+		t.Errorf("Error. Expected validate to fail.")
+	}
+}
+
+type pathTestCommand struct {
+	SubcommandNoOp
+	path string
+}
+
+func (self *pathTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewPathFlag(&self.path, PathFile), "config", "config file")
+	return self
+}
+
+func TestValidateFlags(t *testing.T) {
+	dir := t.TempDir()
+	cmd := &pathTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "cmd"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	root.SetupSubcommand()
+
+	cmd.FlagSet().Parse([]string{"-config", dir})
+	errs := ValidateFlags(cmd.FlagSet())
+	if len(errs) != 1 {
+		t.Fatalf("Error. Expected: 1. Received: %d.", len(errs))
+	}
+	if !strings.HasPrefix(errs[0].Error(), "-config: ") {
+		t.Errorf("Error. Expected the flag name in the error. Received: %s", errs[0])
+	}
+
+	// Subcommands checks before Execute.
+	rc := Invoke(context.Background(), root, []string{"tool", "cmd", "-config", dir})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	// The value left by the last run is the default now, which is
+	// checked too.
+	rc = Invoke(context.Background(), root, []string{"tool", "cmd"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	cmd.path = ""
+	rc = Invoke(context.Background(), root, []string{"tool", "cmd"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+}
+
+type pathDefaultTestCommand struct {
+	SubcommandNoOp
+	out string
+}
+
+func (self *pathDefaultTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ContinueOnError)
+	self.flagSet.Var(NewPathFlag(&self.out, PathExpand, PathDir), "out", "output directory")
+	return self
+}
+
+func TestPathFlagDefault(t *testing.T) {
+	dir := t.TempDir()
+	vars := map[string]string{"HOME": dir, "USERPROFILE": dir}
+	env := &Environment{LookupEnv: func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}}
+
+	// The default is expanded like the values given.
+	cmd := &pathDefaultTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "cmd"}, out: "~"}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	rc := Invoke(context.Background(), root, []string{"tool", "cmd"}, WithEnvironment(env), ContinueOnError())
+	if rc != 0 || cmd.out != dir {
+		t.Errorf("Error. Expected: 0 %s. Received: %d %s.", dir, rc, cmd.out)
+	}
+
+	// And checked.
+	cmd = &pathDefaultTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "cmd"}, out: "~/missing"}
+	root = &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	rc = Invoke(context.Background(), root, []string{"tool", "cmd"}, WithEnvironment(env), ContinueOnError())
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
+
+func TestPathExpandEnvironment(t *testing.T) {
+	vars := map[string]string{"HOME": "/home/test", "USERPROFILE": "/home/test", "GLARG_TEST_DIR": "/data"}
+	env := (&Environment{LookupEnv: func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}}).withDefaults()
+
+	var path string
+	f := NewPathFlag(&path, PathExpand)
+	f.useEnvironment(env)
+	cases := map[string]string{
+		"~/config.yaml":               filepath.Join("/home/test", "config.yaml"),
+		"$GLARG_TEST_DIR/config.yaml": "/data/config.yaml",
+		"${GLARG_UNSET}config.yaml":   "config.yaml",
+	}
+	for input, expected := range cases {
+		if err := f.Set(input); err != nil || path != expected {
+			t.Errorf("Error. Input: %s. Expected: %s. Received: %s %v.", input, expected, path, err)
+		}
+	}
+
+	var paths []string
+	slice := NewSliceFlag(&PathSliceFlagTarget{Target: &paths, Options: []PathOption{PathExpand}}, "")
+	slice.useEnvironment(env)
+	if err := slice.Set("$GLARG_TEST_DIR/a,~/b"); err != nil || strings.Join(paths, ",") != "/data/a,"+filepath.Join("/home/test", "b") {
+		t.Errorf("Error. Expected: /data/a,/home/test/b. Received: %v %v.", paths, err)
+	}
+
+	delete(vars, "HOME")
+	delete(vars, "USERPROFILE")
+	if err := f.Set("~/config.yaml"); err == nil {
+		t.Errorf("Error. Expected ~ to fail without a home.")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package glarg
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// canWrite asks access(2) whether the user can write to s. Creating
+// a file in a directory also needs to search it.
+func canWrite(s string, info os.FileInfo) bool {
+	mode := uint32(unix.W_OK)
+	if info.IsDir() {
+		mode |= unix.X_OK
+	}
+	return unix.Access(s, mode) == nil
+}
@@ -0,0 +1,217 @@
+package glarg
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// Plugin is an external executable named <prefix>-<name>
+// found on the PATH or in the PluginDirs of a Subcommands.
+// The prefix is the names of the Subcommands from the root
+// joined by "-", like git-<name> or tool-remote-<name>.
+type Plugin struct {
+	Name string
+	Path string
+}
+
+// pluginPrefix returns the executable name prefix for the
+// plugins of self.
+func (self *Subcommands) pluginPrefix() string {
+	if self.prefix == "" {
+		return self.Name
+	}
+	return self.prefix
+}
+
+// pluginDirs returns the directories searched for plugins. Like
+// exec.LookPath, relative directories, including the empty and "."
+// entries of the PATH, are skipped so that nothing is run from the
+// current directory.
+func (self *Subcommands) pluginDirs() []string {
+	dirs := []string{}
+	for _, v := range append(append([]string{}, self.PluginDirs...), filepath.SplitList(self.environment().Getenv("PATH"))...) {
+		if filepath.IsAbs(v) {
+			dirs = append(dirs, v)
+		}
+	}
+	return dirs
+}
+
+// isNestedPlugin is true when name is the plugin of a nested
+// Subcommands, like remote-add for tool-remote-add, which is not
+// one of ours.
+func (self *Subcommands) isNestedPlugin(name string) bool {
+	for _, v := range self.Children {
+		if nested, ok := v.(*Subcommands); ok && strings.HasPrefix(name, nested.Name+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// FindPlugins returns every plugin available to self, sorted by
+// name. When a name is found in several directories, the first
+// one wins, with the PluginDirs searched before the PATH.
+func (self *Subcommands) FindPlugins() []Plugin {
+	if !self.ExternalPlugins {
+		return []Plugin{}
+	}
+
+	prefix := self.pluginPrefix() + "-"
+	found := map[string]Plugin{}
+	for _, v := range self.Children {
+		// Built in commands hide plugins of the same name.
+		found[v.FlagSet().Name()] = Plugin{}
+	}
+	for _, dir := range self.pluginDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, v := range entries {
+			name := strings.TrimSuffix(v.Name(), ".exe")
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+				continue
+			}
+			name = name[len(prefix):]
+			if self.isNestedPlugin(name) {
+				continue
+			}
+			path := filepath.Join(dir, v.Name())
+			if _, ok := found[name]; !ok && isExecutable(path) {
+				found[name] = Plugin{Name: name, Path: path}
+			}
+		}
+	}
+
+	plugins := make([]Plugin, 0, len(found))
+	for _, v := range found {
+		if v.Path != "" {
+			plugins = append(plugins, v)
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// lookupPlugin finds the plugin for name, if there is one.
+func (self *Subcommands) lookupPlugin(name string) (Plugin, bool) {
+	if !self.ExternalPlugins || name == "" || strings.ContainsAny(name, `/\`) || self.isNestedPlugin(name) {
+		return Plugin{}, false
+	}
+
+	file := self.pluginPrefix() + "-" + name
+	for _, dir := range self.pluginDirs() {
+		for _, v := range []string{file, file + ".exe"} {
+			path := filepath.Join(dir, v)
+			if isExecutable(path) {
+				return Plugin{Name: name, Path: path}, true
+			}
+		}
+	}
+	return Plugin{}, false
+}
+
+// pluginCommand runs a Plugin as a Subcommand, so that it goes
+// through the same lifecycle as the built in commands. It does
+// not parse any flags, they are all handed to the plugin.
+type pluginCommand struct {
+	flagSet *flag.FlagSet
+	args    []string
+	plugin  Plugin
+}
+
+func newPluginCommand(plugin Plugin) *pluginCommand {
+	self := &pluginCommand{plugin: plugin}
+	self.SetupSubcommand()
+	return self
+}
+
+func (self *pluginCommand) Description() string {
+	return fmt.Sprintf("Plugin %s", self.plugin.Path)
+}
+
+func (self *pluginCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *pluginCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.plugin.Name, flag.ContinueOnError)
+	return self
+}
+
+func (self *pluginCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *pluginCommand) SetArgs(args []string) {
+	self.args = args
+}
+
+// Execute runs the plugin with our stdio, forwarding interrupts
+// and terminations to it, and returns its exit code.
+func (self *pluginCommand) Execute(ctx context.Context) int {
+	args := []string{}
+	if len(self.args) > 1 {
+		args = self.args[1:]
+	}
+
+	env := Env(ctx)
+	cmd := exec.Command(self.plugin.Path, args...)
+	cmd.Stdin = env.Stdin
+	cmd.Stdout = env.Stdout
+	cmd.Stderr = env.Stderr
+	cmd.Env = env.environ()
+
+	sigChan := make(chan os.Signal, 1)
+	env.Signals.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer env.Signals.Stop(sigChan)
+
+	if err := cmd.Start(); err != nil {
+		env.logf(slog.LevelError, "Unable to run plugin %s: %s", self.plugin.Path, err)
+		return 126
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigChan:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode()
+		}
+		env.logf(slog.LevelError, "Plugin %s failed: %s", self.plugin.Path, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+package glarg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir string, name string, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	writePlugin(t, dir, "root-hello", `echo "$@" > `+out+`; exit 0`)
+	writePlugin(t, dir, "root-fail", "exit 42")
+	writePlugin(t, dir, "root-empty", "exit 9")
+	writePlugin(t, dir, "root-remote-add", "exit 7")
+	os.WriteFile(filepath.Join(dir, "root-notexec"), []byte("#!/bin/sh\n"), 0644)
+	t.Setenv("PATH", "")
+
+	remote := &Subcommands{Name: "remote", ExternalPlugins: true, PluginDirs: []string{dir}}
+	root := &Subcommands{
+		Name:            "root",
+		ExternalPlugins: true,
+		PluginDirs:      []string{dir},
+		Children:        []Subcommand{&SubcommandNoOp{Name: "empty"}, remote},
+	}
+	root.SetupSubcommand()
+
+	names := []string{}
+	for _, v := range root.FindPlugins() {
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, " ") != "fail hello" {
+		t.Errorf("Error. Expected: fail hello. Received: %v.", names)
+	}
+	if matches := Complete(root, []string{"h"}); len(matches) != 1 || matches[0] != "hello" {
+		t.Errorf("Error. Expected: [hello]. Received: %v.", matches)
+	}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "hello", "-x", "world"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if content, _ := os.ReadFile(out); string(content) != "-x world\n" {
+		t.Errorf("Error. Expected: -x world. Received: %q.", content)
+	}
+
+	cases := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"tool", "fail"}, 42},
+		{[]string{"tool", "empty"}, 0},
+		{[]string{"tool", "remote", "add"}, 7},
+		{[]string{"tool", "remote-add"}, 1},
+		{[]string{"tool", "notexec"}, 1},
+		{[]string{"tool", "missing"}, 1},
+	}
+	for _, v := range cases {
+		if rc := Invoke(context.Background(), root, v.args); rc != v.expected {
+			t.Errorf("Error. Args: %v. Expected: %d. Received: %d.", v.args, v.expected, rc)
+		}
+	}
+
+	// Plugins get the variables of the Environment.
+	writePlugin(t, dir, "root-greet", `echo "$GLARG_GREETING" > `+out)
+	env := &Environment{
+		LookupEnv: func(key string) (string, bool) {
+			if key == "GLARG_GREETING" {
+				return "hi", true
+			}
+			return os.LookupEnv(key)
+		},
+		Environ: func() []string {
+			return append(os.Environ(), "GLARG_GREETING=")
+		},
+	}
+	if rc := Invoke(context.Background(), root, []string{"tool", "greet"}, WithEnvironment(env)); rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if content, _ := os.ReadFile(out); string(content) != "hi\n" {
+		t.Errorf("Error. Expected: hi. Received: %q.", content)
+	}
+
+	// Plugins are only used when enabled.
+	root.ExternalPlugins = false
+	if rc := Invoke(context.Background(), root, []string{"tool", "fail"}); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
+
+func TestPluginsRelativePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	dir := t.TempDir()
+	writePlugin(t, dir, "root-local", "exit 0")
+	t.Chdir(dir)
+	t.Setenv("PATH", string(filepath.ListSeparator)+".")
+
+	root := &Subcommands{Name: "root", ExternalPlugins: true, PluginDirs: []string{"."}}
+	root.SetupSubcommand()
+	if plugins := root.FindPlugins(); len(plugins) != 0 {
+		t.Errorf("Error. Expected no plugins. Received: %v.", plugins)
+	}
+	if rc := Invoke(context.Background(), root, []string{"tool", "local"}); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
@@ -0,0 +1,254 @@
+package glarg
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompt is a positional argument glarg asks for when it is
+// missing, see ArgumentPrompter.
+type Prompt struct {
+	Name  string
+	Usage string
+	// Checks the answer like a flag value would, and holds it.
+	// When nil any answer which is not empty is accepted.
+	Value flag.Value
+}
+
+// ArgumentPrompter is implemented by a Subcommand taking
+// positional arguments. MissingArgs returns the ones missing from
+// the Args of its FlagSet. The answers are appended to the Args in
+// order before UnpackArgs.
+type ArgumentPrompter interface {
+	MissingArgs() []Prompt
+}
+
+// secretSetter is implemented by flag values which hide what is
+// typed at the prompt, like the SecretFlag.
+type secretSetter interface {
+	setTyped(buf []byte)
+}
+
+// prompter asks for the missing values of a command.
+type prompter struct {
+	in     *bufio.Reader
+	out    io.Writer
+	styler Styler
+	// Reads a line without echoing it.
+	readSecret func() ([]byte, error)
+}
+
+// newPrompter returns the prompter of env, and false when its
+// standard input is not a terminal and nobody can answer.
+func newPrompter(env *Environment) (*prompter, bool) {
+	f, ok := env.Stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return nil, false
+	}
+	return &prompter{
+		in:     bufio.NewReader(f),
+		out:    env.Stderr,
+		styler: env.Styler(env.Stderr),
+		readSecret: func() ([]byte, error) {
+			return term.ReadPassword(int(f.Fd()))
+		},
+	}, true
+}
+
+// promptMissing asks for the flags the constraints of cmd require
+// and are not set, then for its missing positional arguments,
+// which it returns. It stops at the first error, like the end of
+// the input, and leaves the rest to the validation.
+func (self *prompter) promptMissing(cmd Subcommand) ([]string, error) {
+	fs := cmd.FlagSet()
+	if fs == nil {
+		return nil, nil
+	}
+
+	if fc, ok := cmd.(FlagConstrainer); ok {
+		for _, names := range missingFlags(fs, fc.FlagConstraints()) {
+			name := names[0]
+			if len(names) > 1 {
+				var err error
+				if name, err = self.choose(fs, names); err != nil {
+					return nil, err
+				}
+			}
+			f := fs.Lookup(name)
+			if f == nil {
+				continue
+			}
+			err := self.ask("-"+f.Name, f.Usage, f.Value, func(answer string) error {
+				return fs.Set(f.Name, answer)
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if ap, ok := cmd.(ArgumentPrompter); ok {
+		answers := []string{}
+		for _, v := range ap.MissingArgs() {
+			value := v.Value
+			err := self.ask(v.Name, v.Usage, value, func(answer string) error {
+				if value != nil {
+					if err := value.Set(answer); err != nil {
+						return err
+					}
+				}
+				answers = append(answers, answer)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(answers) > 0 {
+			return answers, fs.Parse(append(append([]string{"--"}, fs.Args()...), answers...))
+		}
+	}
+	return nil, nil
+}
+
+// fillIn prompts for what cmd is missing, and returns args, which
+// are given to an ArgumentConsumer, with the positional answers
+// added. An error is only logged, the validation reports what is
+// still missing.
+func (self *prompter) fillIn(env *Environment, cmd Subcommand, args []string) []string {
+	answers, err := self.promptMissing(cmd)
+	if err != nil {
+		env.logf(slog.LevelWarn, "Unable to ask for the missing arguments. %s", err)
+	}
+	return append(args[:len(args):len(args)], answers...)
+}
+
+// missingFlags returns the flags which the constraints require and
+// are not set, in the order of the constraints. The flags of at
+// least one of, and exactly one of, come together, since only one
+// of them has to be given.
+func missingFlags(fs *flag.FlagSet, constraints []FlagConstraint) [][]string {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	missing := [][]string{}
+	add := func(names []string) {
+		for _, v := range names {
+			if !set[v] {
+				set[v] = true
+				missing = append(missing, []string{v})
+			}
+		}
+	}
+	for _, v := range constraints {
+		switch v.Kind {
+		case ConstraintRequired:
+			add(v.Flags)
+		case ConstraintRequiredTogether:
+			for _, name := range v.Flags {
+				if set[name] {
+					add(v.Flags)
+					break
+				}
+			}
+		case ConstraintRequires:
+			if set[v.If] {
+				add(v.Flags)
+			}
+		case ConstraintAtLeastOneOf, ConstraintExactlyOneOf:
+			isSet := false
+			for _, name := range v.Flags {
+				isSet = isSet || set[name]
+			}
+			if !isSet && len(v.Flags) > 0 {
+				missing = append(missing, v.Flags)
+			}
+		}
+	}
+	return missing
+}
+
+// choose asks which one of the flags names to give, and returns it.
+func (self *prompter) choose(fs *flag.FlagSet, names []string) (string, error) {
+	choices := []EnumChoice{}
+	for _, v := range names {
+		if f := fs.Lookup(v); f != nil {
+			choices = append(choices, EnumChoice{Value: v, Description: f.Usage})
+		}
+	}
+	if len(choices) < 2 {
+		return names[0], nil
+	}
+
+	var name string
+	value := NewEnumFlagWithChoices(&name, choices)
+	err := self.ask(flagAlternatives(names), "Which one to give", value, value.Set)
+	return name, err
+}
+
+// ask prompts for the value until set accepts it. Values with
+// choices show them as a numbered list, and secrets are not echoed.
+func (self *prompter) ask(label string, usage string, value flag.Value, set func(answer string) error) error {
+	var choices []EnumChoice
+	if c, ok := value.(interface{ Choices() []EnumChoice }); ok {
+		choices = c.Choices()
+	}
+	secret, isSecret := value.(secretSetter)
+
+	for {
+		answer, empty := "", false
+		if len(choices) > 0 {
+			fmt.Fprintf(self.out, "%s: %s\n", self.styler.Style(STYLE_FLAG, label), usage)
+			for i, v := range choices {
+				fmt.Fprintf(self.out, "  %d) %s  %s\n", i+1, v.Value, v.Description)
+			}
+			fmt.Fprintf(self.out, "%s [1-%d]: ", self.styler.Style(STYLE_FLAG, label), len(choices))
+		} else {
+			fmt.Fprintf(self.out, "%s (%s): ", self.styler.Style(STYLE_FLAG, label), usage)
+		}
+
+		if isSecret {
+			buf, err := self.readSecret()
+			fmt.Fprintln(self.out)
+			if err != nil {
+				return err
+			}
+			if empty = len(buf) == 0; !empty {
+				secret.setTyped(buf)
+			}
+		} else {
+			line, err := self.in.ReadString('\n')
+			if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+				return err
+			}
+			answer = strings.TrimSpace(line)
+			if i, err := strconv.Atoi(answer); err == nil && i > 0 && i <= len(choices) {
+				answer = choices[i-1].Value
+			}
+			empty = answer == ""
+		}
+
+		err := fmt.Errorf("a value is required")
+		if !empty {
+			err = set(answer)
+		}
+		if v, ok := value.(Validator); ok && err == nil {
+			err = v.Validate()
+		}
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintf(self.out, "%s\n", self.styler.Style(STYLE_ERROR, err.Error()))
+	}
+}
@@ -0,0 +1,177 @@
+package glarg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type promptTestCommand struct {
+	SubcommandNoOp
+	id       uuid.UUID
+	format   string
+	password Secret
+	name     string
+}
+
+func (self *promptTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ContinueOnError)
+	self.flagSet.Var(NewUUIDFlag(&self.id), "id", "The id")
+	self.flagSet.Var(NewEnumFlagWithChoices(&self.format, []EnumChoice{{"text", "Plain."}, {"json", "JSON."}}), "format", "The format")
+	self.flagSet.Var(NewSecretFlag(&self.password), "password", "The password")
+	self.flagSet.StringVar(&self.name, "name", "", "The name")
+	return self
+}
+
+func (self *promptTestCommand) FlagConstraints() []FlagConstraint {
+	return []FlagConstraint{Required("id", "format"), Requires("name", "password")}
+}
+
+func (self *promptTestCommand) MissingArgs() []Prompt {
+	if self.flagSet.NArg() > 0 {
+		return nil
+	}
+	return []Prompt{{Name: "target", Usage: "Where to"}}
+}
+
+func TestPromptMissing(t *testing.T) {
+	id := uuid.New()
+	cmd := &promptTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}
+	cmd.SetupSubcommand()
+	cmd.FlagSet().Parse([]string{"-name", "me"})
+
+	var out bytes.Buffer
+	p := &prompter{
+		in:  bufio.NewReader(strings.NewReader("nope\n" + id.String() + "\n3\n2\n\nhome\n")),
+		out: &out,
+		readSecret: func() ([]byte, error) {
+			return []byte("hunter2"), nil
+		},
+	}
+	if _, err := p.promptMissing(cmd); err != nil {
+		t.Fatalf("Error. Expected: nil. Received: %s.", err)
+	}
+
+	if cmd.id != id || cmd.format != "json" || cmd.password.Reveal() != "hunter2" {
+		t.Errorf("Error. Expected: %s json hunter2. Received: %s %s %s.", id, cmd.id, cmd.format, cmd.password.Reveal())
+	}
+	if args := cmd.FlagSet().Args(); len(args) != 1 || args[0] != "home" {
+		t.Errorf("Error. Expected: [home]. Received: %v.", args)
+	}
+	if errs := CheckFlagConstraints(cmd.FlagSet(), cmd.FlagConstraints()); len(errs) > 0 {
+		t.Errorf("Error. Expected the prompted flags to be set. Received: %v.", errs)
+	}
+
+	expected := "" +
+		"-id (The id): invalid UUID length: 4\n" +
+		"-id (The id): " +
+		"-format: The format\n  1) text  Plain.\n  2) json  JSON.\n-format [1-2]: " +
+		"invalid value \"3\", must be one of: text, json\n" +
+		"-format: The format\n  1) text  Plain.\n  2) json  JSON.\n-format [1-2]: " +
+		"-password (The password): \n" +
+		"target (Where to): a value is required\n" +
+		"target (Where to): "
+	if out.String() != expected {
+		t.Errorf("Error. Expected:\n%q\nReceived:\n%q", expected, out.String())
+	}
+}
+
+func TestPromptMissingEOF(t *testing.T) {
+	cmd := &promptTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}
+	cmd.SetupSubcommand()
+	cmd.FlagSet().Parse([]string{})
+
+	p := &prompter{in: bufio.NewReader(strings.NewReader("")), out: &bytes.Buffer{}}
+	if _, err := p.promptMissing(cmd); err == nil {
+		t.Errorf("Error. Expected: EOF. Received: nil.")
+	}
+}
+
+func TestPromptMissingNotInteractive(t *testing.T) {
+	var logs bytes.Buffer
+	root := &Subcommands{
+		Name:          "root",
+		PromptMissing: true,
+		Children:      []Subcommand{&promptTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}},
+	}
+	env := &Environment{Stdin: strings.NewReader("text\n"), Stderr: &logs, Logger: log.New(&logs, "", 0)}
+	if rc := Invoke(context.Background(), root, []string{"tool", "login"}, WithEnvironment(env)); rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(logs.String(), "Invalid arguments. -id and -format are required") {
+		t.Errorf("Error. Expected the usage error. Received: %s.", logs.String())
+	}
+}
+
+func TestPromptMissingOneOf(t *testing.T) {
+	cmd := &constraintTestCommand{SubcommandNoOp{Name: "get"}}
+	cmd.SetupSubcommand()
+	cmd.FlagSet().Parse([]string{})
+
+	var out bytes.Buffer
+	p := &prompter{in: bufio.NewReader(strings.NewReader("2\nhttp://x\n")), out: &out}
+	if _, err := p.promptMissing(cmd); err != nil {
+		t.Fatalf("Error. Expected: nil. Received: %s.", err)
+	}
+	if errs := CheckFlagConstraints(cmd.FlagSet(), cmd.FlagConstraints()); len(errs) > 0 {
+		t.Errorf("Error. Expected the prompted flags to be set. Received: %v.", errs)
+	}
+	if v := cmd.FlagSet().Lookup("url").Value.String(); v != "http://x" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "http://x", v)
+	}
+
+	expected := "-id or -url: Which one to give\n  1) id  id\n  2) url  url\n-id or -url [1-2]: -url (url): "
+	if out.String() != expected {
+		t.Errorf("Error. Expected:\n%q\nReceived:\n%q", expected, out.String())
+	}
+}
+
+func TestPromptMissingInherited(t *testing.T) {
+	nested := &Subcommands{Name: "nested", Children: []Subcommand{&promptTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}}}
+	root := &Subcommands{Name: "root", PromptMissing: true, Children: []Subcommand{nested}}
+	root.SetupSubcommand()
+	if !nested.promptMissing() || nested.PromptMissing {
+		t.Errorf("Error. Expected the nested Subcommands to inherit PromptMissing without changing it.")
+	}
+	root.PromptMissing = false
+	if nested.promptMissing() {
+		t.Errorf("Error. Expected: false. Received: true.")
+	}
+}
+
+type promptConsumerTestCommand struct {
+	promptTestCommand
+	args []string
+}
+
+func (self *promptConsumerTestCommand) SetupSubcommand() Subcommand {
+	self.promptTestCommand.SetupSubcommand()
+	return self
+}
+
+func (self *promptConsumerTestCommand) SetArgs(args []string) {
+	self.args = args
+}
+
+func TestPromptMissingArgumentConsumer(t *testing.T) {
+	cmd := &promptConsumerTestCommand{promptTestCommand: promptTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}}
+	cmd.SetupSubcommand()
+	args := []string{"login", "-id", uuid.New().String(), "-format", "text"}
+	cmd.FlagSet().Parse(args[1:])
+
+	p := &prompter{in: bufio.NewReader(strings.NewReader("home\n")), out: &bytes.Buffer{}}
+	var consumer ArgumentConsumer = cmd
+	consumer.SetArgs(p.fillIn(DefaultEnvironment(), cmd, args))
+	if len(cmd.args) != len(args)+1 || cmd.args[len(args)] != "home" {
+		t.Errorf("Error. Expected the answer in the args. Received: %v.", cmd.args)
+	}
+	if len(args) != 5 {
+		t.Errorf("Error. Expected the args to be left alone. Received: %v.", args)
+	}
+}
@@ -0,0 +1,204 @@
+package glarg
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// REDACTED is shown in place of a secret value.
+const REDACTED = "[REDACTED]"
+
+// Secret holds a sensitive value like an API key. It never
+// prints its value, use Bytes or Reveal to get at it and
+// Zero once it is no longer needed.
+type Secret struct {
+	buf []byte
+}
+
+func NewSecret(b []byte) *Secret {
+	return &Secret{buf: b}
+}
+
+// IsSet is true when the secret holds a value.
+func (self *Secret) IsSet() bool {
+	return self != nil && len(self.buf) > 0
+}
+
+// Bytes returns the secret without copying it.
+func (self *Secret) Bytes() []byte {
+	if self == nil {
+		return nil
+	}
+	return self.buf
+}
+
+// Reveal returns the secret as a string. The string can not
+// be zeroed, prefer Bytes where possible.
+func (self *Secret) Reveal() string {
+	return string(self.Bytes())
+}
+
+// Zero overwrites the secret in memory and empties it.
+func (self *Secret) Zero() {
+	if self == nil {
+		return
+	}
+	for i := range self.buf {
+		self.buf[i] = 0
+	}
+	self.buf = self.buf[:0]
+}
+
+// The redacting methods have value receivers so that a Secret
+// held by value, like a struct field, prints redacted too.
+func (self Secret) String() string {
+	if len(self.buf) == 0 {
+		return ""
+	}
+	return REDACTED
+}
+
+func (self Secret) GoString() string {
+	return self.String()
+}
+
+// Format makes sure every fmt verb, including %#v and %x,
+// prints the redacted form.
+func (self Secret) Format(f fmt.State, verb rune) {
+	io.WriteString(f, self.String())
+}
+
+func (self Secret) MarshalText() ([]byte, error) {
+	return []byte(self.String()), nil
+}
+
+// Secret flag getter. Reads a secret from "@file", "env:NAME"
+// or "-" for stdin so that it does not show up in the process
+// list. Literal values are refused unless AllowLiteral is used.
+// This type implements the flag.Getter interface, Get returns
+// the *Secret.
+type SecretFlag struct {
+	ptr          *Secret
+	allowLiteral bool
+	env          *Environment
+	// A Secret, so that the flag prints redacted with %+v too.
+	typed *Secret
+}
+
+func NewSecretFlag(v *Secret) *SecretFlag {
+	return &SecretFlag{
+		ptr: v,
+	}
+}
+
+// AllowLiteral accepts the secret itself as the flag value.
+// The value is still redacted, but is visible to ps.
+func (self *SecretFlag) AllowLiteral() *SecretFlag {
+	self.allowLiteral = true
+	return self
+}
+
+func (self SecretFlag) String() string {
+	if self.ptr == nil {
+		return ""
+	}
+	return self.ptr.String()
+}
+
+func (self *SecretFlag) Set(s string) error {
+	if self.ptr == nil {
+		self.ptr = &Secret{}
+	}
+
+	var buf []byte
+	var err error
+	switch {
+	case self.typed != nil:
+		buf, self.typed = self.typed.Bytes(), nil
+	case s == STDIO_PATH:
+		buf, err = io.ReadAll(self.environment().Stdin)
+	case strings.HasPrefix(s, "@"):
+		buf, err = os.ReadFile(s[1:])
+	case strings.HasPrefix(s, "env:"):
+		if v, ok := self.environment().LookupEnv(s[4:]); !ok {
+			err = fmt.Errorf("environment variable %s is not set", s[4:])
+		} else {
+			buf = []byte(v)
+		}
+	case self.allowLiteral:
+		buf = []byte(s)
+	default:
+		err = fmt.Errorf("secrets must be passed as @file, env:NAME or - for stdin")
+	}
+	if err != nil {
+		return err
+	}
+
+	// Files and stdin usually end with a newline that is not
+	// part of the secret.
+	if end := len(bytes.TrimRight(buf, "\r\n")); end < len(buf) {
+		for i := end; i < len(buf); i++ {
+			buf[i] = 0
+		}
+		buf = buf[:end]
+	}
+	if len(buf) == 0 {
+		return fmt.Errorf("secret is empty")
+	}
+
+	self.ptr.Zero()
+	self.ptr.buf = buf
+	return nil
+}
+
+// setTyped makes the next Set store buf, the secret typed at a
+// prompt, whatever the value given to Set.
+func (self *SecretFlag) setTyped(buf []byte) {
+	self.typed = NewSecret(buf)
+}
+
+func (self *SecretFlag) useEnvironment(env *Environment) {
+	self.env = env
+}
+
+func (self SecretFlag) environment() *Environment {
+	if self.env == nil {
+		return DefaultEnvironment()
+	}
+	return self.env
+}
+
+func (self SecretFlag) Get() interface{} {
+	if self.ptr == nil {
+		return &Secret{}
+	} else {
+		return self.ptr
+	}
+}
+
+// Reset zeroes the secret. See Resetter.
+func (self *SecretFlag) Reset() {
+	self.ptr.Zero()
+}
+
+// snapshot returns a copy of the secret, which the redacted
+// String can not give back. See snapshotter.
+func (self *SecretFlag) snapshot() interface{} {
+	return bytes.Clone(self.ptr.Bytes())
+}
+
+func (self *SecretFlag) restore(v interface{}) {
+	if self.ptr == nil {
+		return
+	}
+	self.ptr.Zero()
+	self.ptr.buf = bytes.Clone(v.([]byte))
+}
+
+// Zero overwrites the secret held by the flag.
+func (self *SecretFlag) Zero() {
+	self.ptr.Zero()
+}
@@ -0,0 +1,127 @@
+package glarg
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretFlag(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GLARG_TEST_TOKEN", "from-env")
+
+	var secret Secret
+	flag1 := NewSecretFlag(&secret)
+
+	if flag1.String() != "" {
+		t.Errorf("Error. Expected an empty string. Received: %s.", flag1.String())
+	}
+
+	if err := flag1.Set("@" + file); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	} else if secret.Reveal() != "from-file" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "from-file", secret.Reveal())
+	}
+
+	if err := flag1.Set("env:GLARG_TEST_TOKEN"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	} else if secret.Reveal() != "from-env" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "from-env", secret.Reveal())
+	}
+
+	flag1.useEnvironment((&Environment{Stdin: strings.NewReader("from-stdin\r\n")}).withDefaults())
+	if err := flag1.Set("-"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	} else if secret.Reveal() != "from-stdin" {
+		t.Errorf("Error. Expected: %s. Received: %s.", "from-stdin", secret.Reveal())
+	}
+
+	for _, v := range []string{"literal", "env:GLARG_TEST_MISSING", "@" + filepath.Join(dir, "missing")} {
+		if err := flag1.Set(v); err == nil {
+			t.Errorf("Error. Expected set of %s to fail.", v)
+		}
+	}
+
+	if err := NewSecretFlag(&secret).AllowLiteral().Set("literal"); err != nil {
+		t.Errorf("Error. Expected set to work. Received: %s", err)
+	}
+
+	// The value never shows up when printed.
+	for _, v := range []string{
+		flag1.String(),
+		fmt.Sprintf("%v", &secret),
+		fmt.Sprintf("%s", &secret),
+		fmt.Sprintf("%#v", &secret),
+		fmt.Sprintf("%x", &secret),
+		fmt.Sprintf("%v", flag1.Get()),
+	} {
+		if v != REDACTED {
+			t.Errorf("Error. Expected: %s. Received: %s.", REDACTED, v)
+		}
+	}
+
+	// Nor in the help.
+	var out bytes.Buffer
+	fs := flag.NewFlagSet("SecretFlag", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	fs.Var(flag1, "token", "API token.")
+	fs.PrintDefaults()
+	if strings.Contains(out.String(), "literal") {
+		t.Errorf("Error. The secret leaked into the help: %s", out.String())
+	}
+
+	buf := secret.Bytes()
+	flag1.Zero()
+	if secret.IsSet() {
+		t.Errorf("Error. Expected the secret to be empty.")
+	}
+	for _, v := range buf[:cap(buf)][:len("literal")] {
+		if v != 0 {
+			t.Errorf("Error. Expected the buffer to be zeroed. Received: %q", buf[:cap(buf)])
+			break
+		}
+	}
+}
+
+func TestSecretByValue(t *testing.T) {
+	config := struct {
+		Token Secret
+	}{*NewSecret([]byte("hunter2"))}
+
+	for _, v := range []string{
+		fmt.Sprintf("%v", config),
+		fmt.Sprintf("%+v", config),
+		fmt.Sprintf("%#v", config),
+		fmt.Sprintf("%s", config.Token),
+	} {
+		if strings.Contains(v, "hunter2") || strings.Contains(v, "104") || !strings.Contains(v, REDACTED) {
+			t.Errorf("Error. Expected: %s. Received: %s.", REDACTED, v)
+		}
+	}
+	if b, err := json.Marshal(config); err != nil || string(b) != `{"Token":"[REDACTED]"}` {
+		t.Errorf("Error. Expected: %s. Received: %s %v.", REDACTED, b, err)
+	}
+
+	// A secret typed at a prompt is kept redacted by the flag until
+	// Set.
+	var token Secret
+	f := NewSecretFlag(&token)
+	f.setTyped([]byte("hunter2"))
+	for _, v := range []string{fmt.Sprintf("%+v", *f), fmt.Sprintf("%#v", *f), fmt.Sprintf("%v", f)} {
+		if strings.Contains(v, "hunter2") || strings.Contains(v, "104") {
+			t.Errorf("Error. Expected the typed secret to be redacted. Received: %s.", v)
+		}
+	}
+	if err := f.Set(""); err != nil || token.Reveal() != "hunter2" {
+		t.Errorf("Error. Expected: hunter2. Received: %v.", err)
+	}
+}
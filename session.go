@@ -0,0 +1,183 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"strings"
+)
+
+// Resetter is implemented by flag values and Subcommands which
+// keep state between runs. A Session calls Reset on the
+// Subcommands before setting them up again, and on the flag
+// values before putting their defaults back.
+type Resetter interface {
+	Reset()
+}
+
+// snapshotter is implemented by flag values whose String can not
+// be Set back, like the SecretFlag which is redacted and the
+// PathFlag which expands what it is given. A Session keeps their
+// snapshot from the first run and restores it, instead of Setting
+// the default.
+type snapshotter interface {
+	snapshot() interface{}
+	restore(v interface{})
+}
+
+// Session runs several command lines through the same tree, one
+// after the other, in one process. Between runs the tree is setup
+// again and every flag is put back to the default it had before
+// the first run, so nothing leaks from one command to the next.
+// Flag errors are returned as exit code 2 instead of exiting, see
+// ContinueOnError.
+type Session struct {
+	Root Subcommand
+	// The program name passed to Invoke as args[0].
+	Prog      string
+	defaults  map[string]map[string]string
+	snapshots map[string]map[string]interface{}
+}
+
+func NewSession(root Subcommand, prog string) *Session {
+	return &Session{
+		Root: root,
+		Prog: prog,
+	}
+}
+
+// Run invokes the tree with args, which do not include the
+// program name.
+func (self *Session) Run(ctx context.Context, args []string, opts ...InvokeOption) int {
+	walkCommands(self.Root, nil, func(path []string, cmd Subcommand) {
+		if r, ok := cmd.(Resetter); ok {
+			r.Reset()
+		}
+	})
+
+	opts = append(opts, ContinueOnError(), func(inv *invocation) {
+		inv.afterSetup = append(inv.afterSetup, self.restoreDefaults)
+	})
+	return Invoke(ctx, self.Root, append([]string{self.Prog}, args...), opts...)
+}
+
+// restoreDefaults records the defaults of every flag the first
+// time, and puts them back every time after that. Flags bound to
+// variables with Var keep their value across SetupSubcommand,
+// which would also make it the new default.
+func (self *Session) restoreDefaults(root Subcommand) {
+	first := self.defaults == nil
+	if first {
+		self.defaults = map[string]map[string]string{}
+		self.snapshots = map[string]map[string]interface{}{}
+	}
+
+	walkCommands(root, nil, func(path []string, cmd Subcommand) {
+		fs := cmd.FlagSet()
+		if fs == nil {
+			return
+		}
+		key := strings.Join(path, " ")
+
+		if first || self.defaults[key] == nil {
+			defaults := map[string]string{}
+			snapshots := map[string]interface{}{}
+			fs.VisitAll(func(f *flag.Flag) {
+				defaults[f.Name] = f.DefValue
+				if s, ok := f.Value.(snapshotter); ok {
+					snapshots[f.Name] = s.snapshot()
+				}
+			})
+			self.defaults[key] = defaults
+			self.snapshots[key] = snapshots
+			return
+		}
+
+		defaults, snapshots := self.defaults[key], self.snapshots[key]
+		fs.VisitAll(func(f *flag.Flag) {
+			def, ok := defaults[f.Name]
+			if !ok {
+				return
+			}
+			if r, ok := f.Value.(Resetter); ok {
+				r.Reset()
+			}
+			if s, ok := f.Value.(snapshotter); ok {
+				if v, ok := snapshots[f.Name]; ok {
+					s.restore(v)
+				}
+			} else if f.Value.String() != def {
+				f.Value.Set(def)
+			}
+			f.DefValue = def
+		})
+	})
+}
+
+type sessionOptionsKey struct{}
+
+// sessionOptions returns the options of the Invoke running ctx
+// which the command lines of a shell or a batch are run with too.
+// The clock and the Environment are already in ctx.
+func sessionOptions(ctx context.Context) []InvokeOption {
+	opts, _ := ctx.Value(sessionOptionsKey{}).([]InvokeOption)
+	return opts
+}
+
+func (self *invocation) sessionOptions() []InvokeOption {
+	opts := []InvokeOption{}
+	if self.recoverPanics {
+		opts = append(opts, RecoverPanics(self.crashDir))
+	}
+	return opts
+}
+
+// ContinueOnError makes Invoke switch every FlagSet of the tree
+// to flag.ContinueOnError after the setup, so that a bad flag
+// returns exit code 2, and -h exit code 0, instead of exiting the
+// process.
+func ContinueOnError() InvokeOption {
+	return func(self *invocation) {
+		self.afterSetup = append(self.afterSetup, func(root Subcommand) {
+			walkCommands(root, nil, func(path []string, cmd Subcommand) {
+				if fs := cmd.FlagSet(); fs != nil {
+					fs.Init(fs.Name(), flag.ContinueOnError)
+				}
+			})
+		})
+	}
+}
+
+// walkCommands calls fn for cmd and every command below it with
+// the names of the commands from cmd down.
+func walkCommands(cmd Subcommand, path []string, fn func(path []string, cmd Subcommand)) {
+	if cmd.FlagSet() != nil {
+		path = append(path[:len(path):len(path)], cmd.FlagSet().Name())
+	}
+	fn(path, cmd)
+	if sc, ok := cmd.(*Subcommands); ok {
+		for _, v := range sc.Children {
+			walkCommands(v, path, fn)
+		}
+	}
+}
+
+// addFlags adds the flags define puts in a FlagSet to every command
+// below root. The FlagSets of Subcommands are left alone, since
+// only the one of the command which is run has all of its flags
+// parsed. Commands which define a flag of the same name keep their
+// own.
+func addFlags(root Subcommand, define func(cmd Subcommand, fs *flag.FlagSet)) {
+	walkCommands(root, nil, func(path []string, cmd Subcommand) {
+		fs := cmd.FlagSet()
+		if _, ok := cmd.(*Subcommands); ok || fs == nil {
+			return
+		}
+		common := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+		define(cmd, common)
+		common.VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) == nil {
+				fs.Var(f.Value, f.Name, f.Usage)
+			}
+		})
+	})
+}
@@ -0,0 +1,125 @@
+package glarg
+
+import (
+	"context"
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type sessionTestCommand struct {
+	SubcommandNoOp
+	ids     []string
+	id      uuid.UUID
+	name    string
+	resets  int
+	results []string
+}
+
+func (self *sessionTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewSliceFlag(&StringSliceFlagTarget{&self.ids}, ""), "ids", "ids")
+	self.flagSet.Var(NewUUIDFlag(&self.id), "id", "id")
+	self.flagSet.StringVar(&self.name, "name", "default", "name")
+	return self
+}
+
+func (self *sessionTestCommand) Reset() {
+	self.resets++
+}
+
+func (self *sessionTestCommand) Execute(ctx context.Context) int {
+	self.results = append(self.results, strings.Join(self.ids, ",")+"|"+self.id.String()+"|"+self.name)
+	return 0
+}
+
+func TestSession(t *testing.T) {
+	cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	session := NewSession(root, "tool")
+
+	id := uuid.New()
+	lines := [][]string{
+		{"get", "-ids", "a,b", "-id", id.String(), "-name", "x"},
+		{"get"},
+		{"get", "-ids", "c"},
+	}
+	for _, v := range lines {
+		if rc := session.Run(context.Background(), v); rc != 0 {
+			t.Errorf("Error. Args: %v. Expected: 0. Received: %d.", v, rc)
+		}
+	}
+
+	expected := []string{
+		"a,b|" + id.String() + "|x",
+		"|" + uuid.Nil.String() + "|default",
+		"c|" + uuid.Nil.String() + "|default",
+	}
+	if strings.Join(cmd.results, " ") != strings.Join(expected, " ") {
+		t.Errorf("Error. Expected: %v. Received: %v.", expected, cmd.results)
+	}
+	if cmd.resets != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", cmd.resets)
+	}
+
+	// A bad flag returns 2 instead of exiting, and -h returns 0.
+	cmd.FlagSet().SetOutput(&strings.Builder{})
+	if rc := session.Run(context.Background(), []string{"get", "-unknown"}); rc != 2 {
+		t.Errorf("Error. Expected: 2. Received: %d.", rc)
+	}
+	if rc := session.Run(context.Background(), []string{"get", "-h"}); rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	if fs := cmd.FlagSet(); fs.Lookup("ids").DefValue != "" {
+		t.Errorf("Error. Expected an empty default. Received: %s.", fs.Lookup("ids").DefValue)
+	}
+}
+
+type sessionDefaultsTestCommand struct {
+	SubcommandNoOp
+	token   Secret
+	path    string
+	results []string
+}
+
+func (self *sessionDefaultsTestCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.flagSet.Var(NewSecretFlag(&self.token), "token", "token")
+	self.flagSet.Var(NewPathFlag(&self.path), "path", "path")
+	return self
+}
+
+func (self *sessionDefaultsTestCommand) Execute(ctx context.Context) int {
+	self.results = append(self.results, self.token.Reveal()+"|"+self.path)
+	return 0
+}
+
+func TestSessionRestoresSnapshots(t *testing.T) {
+	t.Setenv("GLARG_TEST_TOKEN", "from-env")
+	cmd := &sessionDefaultsTestCommand{
+		SubcommandNoOp: SubcommandNoOp{Name: "get"},
+		token:          *NewSecret([]byte("default-token")),
+		path:           "~/data",
+	}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	session := NewSession(root, "tool")
+
+	lines := [][]string{
+		{"get"},
+		{"get", "-token", "env:GLARG_TEST_TOKEN", "-path", "/tmp/other"},
+		{"get"},
+		{"get"},
+	}
+	for _, v := range lines {
+		if rc := session.Run(context.Background(), v); rc != 0 {
+			t.Errorf("Error. Args: %v. Expected: 0. Received: %d.", v, rc)
+		}
+	}
+
+	expected := "default-token|~/data from-env|/tmp/other default-token|~/data default-token|~/data"
+	if strings.Join(cmd.results, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %v.", expected, cmd.results)
+	}
+}
@@ -0,0 +1,281 @@
+package glarg
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// inSessionKey marks a context as running inside a ShellCommand or
+// BatchCommand, which can not be nested since they setup the tree
+// again for every line.
+type inSessionKey struct{}
+
+// ShellCommand is a Subcommand which reads command lines and
+// runs them through Root, one after the other, in one process.
+// Lines are split with SplitArgs. On a terminal it has line
+// editing, history and tab completion from the tree. Ctrl-C
+// cancels the running command's context, not the shell. The
+// built in "exit" and "quit" leave the shell, "help" shows the
+// Usage of Root.
+type ShellCommand struct {
+	flagSet *flag.FlagSet
+	args    []string
+	Name    string
+	Root    Subcommand
+	// Defaults to the name of Root followed by "> ".
+	Prompt string
+	// Where the history is kept between shells, when set.
+	HistoryFile string
+}
+
+func (self *ShellCommand) Description() string {
+	return "Run commands interactively."
+}
+
+func (self *ShellCommand) FlagSet() *flag.FlagSet {
+	return self.flagSet
+}
+
+func (self *ShellCommand) SetupSubcommand() Subcommand {
+	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	return self
+}
+
+func (self *ShellCommand) HasInvalidFlags() bool {
+	return false
+}
+
+func (self *ShellCommand) SetArgs(args []string) {
+	self.args = args
+}
+
+func (self *ShellCommand) prompt() string {
+	if self.Prompt != "" {
+		return self.Prompt
+	}
+	return self.Root.FlagSet().Name() + "> "
+}
+
+func (self *ShellCommand) Execute(ctx context.Context) int {
+	env := Env(ctx)
+	if ctx.Value(inSessionKey{}) != nil {
+		env.logf(slog.LevelError, "Already in a shell.")
+		return 1
+	}
+
+	// The Invoke which started the shell cancels its context on
+	// Ctrl-C, which must only stop the command being run.
+	ctx = context.WithValue(context.WithoutCancel(ctx), inSessionKey{}, true)
+	sigChan := make(chan os.Signal, 1)
+	env.Signals.Notify(sigChan, os.Interrupt)
+	defer env.Signals.Stop(sigChan)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	session := NewSession(self.Root, self.Root.FlagSet().Name())
+	if f, ok := env.Stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return self.interactive(ctx, session, f)
+	}
+	return self.lines(ctx, session, env.Stdin)
+}
+
+func (self *ShellCommand) lines(ctx context.Context, session *Session, input io.Reader) int {
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		if self.runLine(ctx, session, scanner.Text()) {
+			return 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		Env(ctx).logf(slog.LevelError, "Unable to read commands. %s", err)
+		return 1
+	}
+	return 0
+}
+
+func (self *ShellCommand) interactive(ctx context.Context, session *Session, f *os.File) int {
+	env := Env(ctx)
+	fd := int(f.Fd())
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{&ctrlCReader{r: f}, env.Stdout}, self.prompt())
+	t.AutoCompleteCallback = self.autoComplete(t)
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+
+	history := self.openHistory(env, t)
+	if history != nil {
+		defer history.Close()
+	}
+
+	for {
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return self.lines(ctx, session, f)
+		}
+		line, err := t.ReadLine()
+		term.Restore(fd, state)
+
+		if err == io.EOF {
+			fmt.Fprintln(env.Stdout)
+			return 0
+		} else if err != nil {
+			env.logf(slog.LevelError, "Unable to read commands. %s", err)
+			return 1
+		}
+
+		if history != nil && strings.TrimSpace(line) != "" {
+			fmt.Fprintln(history, line)
+		}
+		if self.runLine(ctx, session, line) {
+			return 0
+		}
+	}
+}
+
+// openHistory loads the HistoryFile into t and returns it open
+// for appending the new lines.
+func (self *ShellCommand) openHistory(env *Environment, t *term.Terminal) *os.File {
+	if self.HistoryFile == "" {
+		return nil
+	}
+	if content, err := os.ReadFile(self.HistoryFile); err == nil {
+		for _, v := range strings.Split(string(content), "\n") {
+			if v != "" {
+				t.History.Add(v)
+			}
+		}
+	}
+	history, err := os.OpenFile(self.HistoryFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		env.logf(slog.LevelWarn, "Unable to open the history. %s", err)
+		return nil
+	}
+	return history
+}
+
+// runLine runs one command line with the options of the Invoke
+// which started the shell, and returns true when the shell should
+// exit.
+func (self *ShellCommand) runLine(ctx context.Context, session *Session, line string) bool {
+	words, err := SplitArgs(line)
+	if err != nil {
+		Env(ctx).logf(slog.LevelError, "Invalid command line. %s", err)
+		return false
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "exit", "quit":
+		return true
+	case "help":
+		if sc, ok := self.Root.(*Subcommands); ok {
+			sc.Usage()
+			return false
+		}
+	}
+
+	session.Run(ctx, words, sessionOptions(ctx)...)
+	return false
+}
+
+func (self *ShellCommand) autoComplete(w io.Writer) func(string, int, rune) (string, int, bool) {
+	return func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+
+		head := line[:pos]
+		words, err := SplitArgs(head)
+		if err != nil {
+			return "", 0, false
+		}
+		if len(words) == 0 || strings.HasSuffix(head, " ") {
+			words = append(words, "")
+		}
+		current := words[len(words)-1]
+		if !strings.HasSuffix(head, current) {
+			// The word is quoted, leave it alone.
+			return "", 0, false
+		}
+
+		matches := Complete(self.Root, words)
+		if len(matches) == 0 {
+			return "", 0, false
+		}
+		completion := commonPrefix(matches)
+		if len(matches) == 1 {
+			completion += " "
+		} else if completion == current {
+			fmt.Fprintf(w, "%s\n", strings.Join(matches, "  "))
+			return "", 0, false
+		}
+
+		head = head[:len(head)-len(current)] + completion
+		return head + line[pos:], len(head), true
+	}
+}
+
+func commonPrefix(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// ctrlCReader turns Ctrl-C into Ctrl-E Ctrl-U, which clears the
+// line being edited, since the Terminal treats Ctrl-C like EOF.
+type ctrlCReader struct {
+	r       io.Reader
+	pending []byte
+	err     error
+}
+
+func (self *ctrlCReader) Read(p []byte) (int, error) {
+	for len(self.pending) == 0 {
+		if self.err != nil {
+			return 0, self.err
+		}
+		buf := make([]byte, len(p))
+		n, err := self.r.Read(buf)
+		for _, v := range buf[:n] {
+			if v == 3 {
+				self.pending = append(self.pending, 5, 21)
+			} else {
+				self.pending = append(self.pending, v)
+			}
+		}
+		self.err = err
+	}
+
+	n := copy(p, self.pending)
+	self.pending = self.pending[n:]
+	return n, nil
+}
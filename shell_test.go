@@ -0,0 +1,91 @@
+package glarg
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellCommand(t *testing.T) {
+	cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	shell := &ShellCommand{Name: "shell", Root: root}
+	root.Children = append(root.Children, shell)
+
+	stdin := strings.NewReader(strings.Join([]string{
+		"get -ids 'a,b' -name \"x y\"",
+		"",
+		"# comment",
+		"get",
+		"get 'unterminated",
+		"shell",
+		"help",
+		"exit",
+		"get -name never",
+	}, "\n"))
+
+	rc := Invoke(context.Background(), root, []string{"tool", "shell"}, WithEnvironment(&Environment{Stdin: stdin}))
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	expected := "a,b|00000000-0000-0000-0000-000000000000|x y |00000000-0000-0000-0000-000000000000|default"
+	if strings.Join(cmd.results, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %v.", expected, cmd.results)
+	}
+}
+
+func TestShellRecoverPanics(t *testing.T) {
+	cmd := &sessionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	boom := &panicTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "boom"}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd, boom}}
+	root.Children = append(root.Children, &ShellCommand{Name: "shell", Root: root})
+
+	dir := t.TempDir()
+	env := &Environment{Stdin: strings.NewReader("boom\nget -name after\n")}
+	rc := Invoke(context.Background(), root, []string{"tool", "shell"}, WithEnvironment(env), RecoverPanics(dir))
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	expected := "|00000000-0000-0000-0000-000000000000|after"
+	if strings.Join(cmd.results, " ") != expected {
+		t.Errorf("Error. Expected: %s. Received: %v.", expected, cmd.results)
+	}
+	if reports, _ := filepath.Glob(filepath.Join(dir, "crash-*.txt")); len(reports) != 1 {
+		t.Errorf("Error. Expected 1 crash report. Received: %v.", reports)
+	}
+}
+
+func TestShellAutoComplete(t *testing.T) {
+	cmd := &completionTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "list"}}
+	root := &Subcommands{
+		Name:     "root",
+		Children: []Subcommand{cmd, &SubcommandNoOp{Name: "login"}, &SubcommandNoOp{Name: "purge"}},
+	}
+	root.SetupSubcommand()
+	shell := &ShellCommand{Name: "shell", Root: root}
+	complete := shell.autoComplete(io.Discard)
+
+	cases := []struct {
+		line     string
+		expected string
+		ok       bool
+	}{
+		{"p", "purge ", true},
+		{"l", "l", false},
+		{"li", "list ", true},
+		{"list -output j", "list -output json ", true},
+		{"list -o", "list -output ", true},
+		{"x", "", false},
+	}
+	for _, v := range cases {
+		line, pos, ok := complete(v.line, len(v.line), '\t')
+		if ok != v.ok || (ok && (line != v.expected || pos != len(v.expected))) {
+			t.Errorf("Error. Line: %q. Expected: %q %v. Received: %q %d %v.", v.line, v.expected, v.ok, line, pos, ok)
+		}
+	}
+	if _, _, ok := complete("p", 1, 'a'); ok {
+		t.Errorf("Error. Expected only tab to complete.")
+	}
+}
@@ -0,0 +1,157 @@
+package glarg
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	COLOR_AUTO   = "auto"
+	COLOR_ALWAYS = "always"
+	COLOR_NEVER  = "never"
+)
+
+// Style is what a piece of text is, which decides how it looks.
+type Style int
+
+const (
+	STYLE_ERROR Style = iota
+	STYLE_WARNING
+	STYLE_FLAG
+	STYLE_COMMAND
+	STYLE_HEADER
+)
+
+// The SGR parameters of each Style.
+var styleCodes = map[Style]string{
+	STYLE_ERROR:   "1;31",
+	STYLE_WARNING: "33",
+	STYLE_FLAG:    "36",
+	STYLE_COMMAND: "1",
+	STYLE_HEADER:  "1",
+}
+
+// Styler styles the text written to one stream. The zero value
+// leaves the text alone.
+type Styler struct {
+	enabled bool
+}
+
+// Enabled is true when the text is styled.
+func (self Styler) Enabled() bool {
+	return self.enabled
+}
+
+// Style returns text with the escape sequences of style around it.
+func (self Styler) Style(style Style, text string) string {
+	if !self.enabled || text == "" {
+		return text
+	}
+	return "\x1b[" + styleCodes[style] + "m" + text + "\x1b[0m"
+}
+
+// Styler returns the Styler for text written to w. The -color
+// flag, or else the Color of the Environment decides, and in auto,
+// which is the default, NO_COLOR and TERM=dumb turn the styles off,
+// FORCE_COLOR turns them on, and otherwise only a terminal gets
+// them.
+func (self *Environment) Styler(w io.Writer) Styler {
+	color := self.Color
+	for _, v := range self.colorFlags {
+		if *v != "" {
+			color = *v
+		}
+	}
+	switch color {
+	case COLOR_ALWAYS:
+		return Styler{true}
+	case COLOR_NEVER:
+		return Styler{}
+	}
+
+	if v := self.Getenv("NO_COLOR"); v != "" {
+		return Styler{}
+	}
+	if v := self.Getenv("FORCE_COLOR"); v != "" {
+		return Styler{v != "0" && v != "false"}
+	}
+	if self.Getenv("TERM") == "dumb" {
+		return Styler{}
+	}
+	f, ok := w.(*os.File)
+	return Styler{ok && term.IsTerminal(int(f.Fd()))}
+}
+
+// addColorFlag adds -color, stored in color, to every command
+// below cmd. See addFlags.
+func addColorFlag(cmd Subcommand, color *string) {
+	addFlags(cmd, func(cmd Subcommand, fs *flag.FlagSet) {
+		f := NewEnumFlag(color, COLOR_AUTO, COLOR_ALWAYS, COLOR_NEVER).IgnoreCase()
+		fs.Var(f, "color", f.Usage("When to color the output"))
+	})
+}
+
+var flagDefaultName = regexp.MustCompile(`(?m)^  (-[^ \n]+)`)
+
+// styleUsage wraps the Usage of fs, the default one or the one set
+// by the command, so that the command and flag names it writes to
+// the output of fs are styled for it.
+func styleUsage(fs *flag.FlagSet, env *Environment) {
+	usage := fs.Usage
+	if usage == nil {
+		usage = func() {
+			if fs.Name() == "" {
+				fmt.Fprintf(fs.Output(), "Usage:\n")
+			} else {
+				fmt.Fprintf(fs.Output(), "Usage of %s:\n", fs.Name())
+			}
+			fs.PrintDefaults()
+		}
+	}
+	fs.Usage = func() {
+		output := fs.Output()
+		styler := env.Styler(output)
+		if !styler.Enabled() {
+			usage()
+			return
+		}
+		var b bytes.Buffer
+		fs.SetOutput(&b)
+		usage()
+		fs.SetOutput(output)
+		text := b.String()
+		if header := "Usage of " + fs.Name() + ":\n"; fs.Name() != "" && strings.HasPrefix(text, header) {
+			text = "Usage of " + styler.Style(STYLE_COMMAND, fs.Name()) + ":\n" + text[len(header):]
+		}
+		io.WriteString(output, styleFlagNames(text, styler))
+	}
+}
+
+// printDefaults is fs.PrintDefaults to w, with the flag names
+// styled.
+func printDefaults(w io.Writer, fs *flag.FlagSet, styler Styler) {
+	var b bytes.Buffer
+	output := fs.Output()
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	fs.SetOutput(output)
+	io.WriteString(w, styleFlagNames(b.String(), styler))
+}
+
+// styleFlagNames styles the flag names at the start of the lines
+// written by PrintDefaults.
+func styleFlagNames(text string, styler Styler) string {
+	if !styler.Enabled() {
+		return text
+	}
+	return flagDefaultName.ReplaceAllStringFunc(text, func(s string) string {
+		return "  " + styler.Style(STYLE_FLAG, s[2:])
+	})
+}
@@ -0,0 +1,155 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentStyler(t *testing.T) {
+	cases := []struct {
+		color    string
+		vars     map[string]string
+		expected bool
+	}{
+		{"", map[string]string{}, false},
+		{COLOR_ALWAYS, map[string]string{}, true},
+		{COLOR_NEVER, map[string]string{"FORCE_COLOR": "1"}, false},
+		{COLOR_AUTO, map[string]string{"FORCE_COLOR": "1"}, true},
+		{COLOR_AUTO, map[string]string{"FORCE_COLOR": "0"}, false},
+		{COLOR_AUTO, map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, false},
+		{COLOR_ALWAYS, map[string]string{"NO_COLOR": "1"}, true},
+	}
+	for _, v := range cases {
+		vars := v.vars
+		env := (&Environment{Color: v.color, LookupEnv: func(key string) (string, bool) {
+			s, ok := vars[key]
+			return s, ok
+		}}).withDefaults()
+		if received := env.Styler(&bytes.Buffer{}).Enabled(); received != v.expected {
+			t.Errorf("Error. Color: %s. Vars: %v. Expected: %t. Received: %t.", v.color, v.vars, v.expected, received)
+		}
+	}
+
+	if received := (Styler{true}).Style(STYLE_ERROR, "failed"); received != "\x1b[1;31mfailed\x1b[0m" {
+		t.Errorf("Error. Expected: the styled text. Received: %q.", received)
+	}
+	if received := (Styler{}).Style(STYLE_ERROR, "failed"); received != "failed" {
+		t.Errorf("Error. Expected: failed. Received: %q.", received)
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	newRoot := func() *Subcommands {
+		return &Subcommands{
+			Name:      "root",
+			ColorFlag: true,
+			Children: []Subcommand{
+				&SubcommandNoOp{Name: "get"},
+				&constraintTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}},
+			},
+		}
+	}
+	run := func(color string, args ...string) string {
+		var stderr, logs bytes.Buffer
+		env := &Environment{Stderr: &stderr, Logger: log.New(&logs, "", 0), Color: color}
+		Invoke(context.Background(), newRoot(), append([]string{"tool"}, args...), WithEnvironment(env), ContinueOnError())
+		return stderr.String() + logs.String()
+	}
+
+	cases := []struct {
+		color    string
+		args     []string
+		expected []string
+	}{
+		{COLOR_ALWAYS, []string{"missing"}, []string{
+			"\x1b[1;31mUnknown subcommand provided: missing.\x1b[0m\n",
+			"  \x1b[1mget       \x1b[0m Not actually implemented.",
+		}},
+		{"", []string{"login", "-color", "always"}, []string{
+			"\x1b[1;31mInvalid arguments.\x1b[0m one of -id or -url is required",
+			"  \x1b[36m-cert\x1b[0m string",
+		}},
+		{"", []string{"get", "-color", "always", "-h"}, []string{
+			"Usage of \x1b[1mget\x1b[0m:\n  \x1b[36m-color\x1b[0m value\n",
+		}},
+		{COLOR_ALWAYS, []string{"login", "-color", "never"}, []string{
+			"Invalid arguments. one of -id or -url is required",
+			"  -cert string",
+		}},
+	}
+	for _, v := range cases {
+		output := run(v.color, v.args...)
+		for _, e := range v.expected {
+			if !strings.Contains(output, e) {
+				t.Errorf("Error. Args: %v. Expected: %q. Received: %q.", v.args, e, output)
+			}
+		}
+	}
+}
+
+func TestColorFlagHelp(t *testing.T) {
+	root := &Subcommands{Name: "root", ColorFlag: true, Children: []Subcommand{&Subcommands{Name: "remote", Children: []Subcommand{&SubcommandNoOp{Name: "get"}}}}}
+	root.SetupSubcommand()
+	if root.FlagSet().Lookup("color") != nil || root.Children[0].FlagSet().Lookup("color") != nil {
+		t.Errorf("Error. Expected no -color on the Subcommands.")
+	}
+
+	info := Describe(root).Children[0].Children[0]
+	f := info.Flag("color")
+	if f == nil || f.Type != "EnumFlag" || len(f.Choices) != 3 || !strings.Contains(f.Usage, "(one of: auto, always, never)") {
+		t.Errorf("Error. Expected -color to be an EnumFlag listing its choices. Received: %+v.", f)
+	}
+}
+
+func TestColorFlagSession(t *testing.T) {
+	var stderr, logs bytes.Buffer
+	root := &Subcommands{Name: "root", ColorFlag: true, Children: []Subcommand{&constraintTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "login"}}}}
+	env := &Environment{Stderr: &stderr, Logger: log.New(&logs, "", 0)}
+	session := NewSession(root, "tool")
+	session.Run(context.Background(), []string{"login", "-color", "always"}, WithEnvironment(env))
+	logs.Reset()
+	session.Run(context.Background(), []string{"login"}, WithEnvironment(env))
+	if strings.Contains(logs.String(), "\x1b[") {
+		t.Errorf("Error. Expected the color of the last run to be gone. Received: %q.", logs.String())
+	}
+}
+
+type styleUsageTestCommand struct {
+	constraintTestCommand
+	output bytes.Buffer
+}
+
+func (self *styleUsageTestCommand) SetupSubcommand() Subcommand {
+	self.constraintTestCommand.SetupSubcommand()
+	self.flagSet.SetOutput(&self.output)
+	self.flagSet.Usage = func() {
+		fmt.Fprintf(self.flagSet.Output(), "Custom usage of %s\n", self.flagSet.Name())
+		self.flagSet.PrintDefaults()
+	}
+	return self
+}
+
+func TestStyleUsage(t *testing.T) {
+	cmd := &styleUsageTestCommand{constraintTestCommand: constraintTestCommand{SubcommandNoOp{Name: "login"}}}
+	root := &Subcommands{Name: "root", Children: []Subcommand{cmd}}
+	var stderr, logs bytes.Buffer
+	env := &Environment{Stderr: &stderr, Logger: log.New(&logs, "", 0), Color: COLOR_ALWAYS}
+
+	// The Usage of the command is kept, and styled.
+	Invoke(context.Background(), root, []string{"tool", "login", "-h"}, WithEnvironment(env), ContinueOnError())
+	if expected := "Custom usage of login\n  \x1b[36m-cert\x1b[0m string\n"; !strings.HasPrefix(cmd.output.String(), expected) {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, cmd.output.String())
+	}
+
+	// The defaults printed with the invalid arguments go to the
+	// Environment, whatever the output of the FlagSet.
+	cmd.output.Reset()
+	Invoke(context.Background(), root, []string{"tool", "login"}, WithEnvironment(env), ContinueOnError())
+	if cmd.output.Len() > 0 || !strings.Contains(stderr.String(), "  \x1b[36m-cert\x1b[0m string\n") {
+		t.Errorf("Error. Expected the defaults on the Environment. Received: %q and %q.", cmd.output.String(), stderr.String())
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"os/signal"
 	"strings"
+	"time"
 )
 
 type Subcommand interface {
@@ -31,6 +31,46 @@ type Subcommands struct {
 	args     []string
 	Name     string
 	Children []Subcommand
+	// The child to run when no subcommand, or only flags,
+	// are given.
+	Default string
+	// Called when no subcommand is given and there is no
+	// Default. When nil, the usage is logged and 1 returned.
+	Bare func(ctx context.Context, self *Subcommands) int
+	// Run executables named <prefix>-<name> found in the
+	// PluginDirs or on the PATH for unknown subcommands. See
+	// Plugin for the prefix.
+	ExternalPlugins bool
+	PluginDirs      []string
+	prefix          string
+	parent          *Subcommands
+	// Replace @path arguments with the arguments read from
+	// path. See ExpandResponseFiles.
+	ResponseFiles bool
+	// How invalid arguments are reported. Nested Subcommands
+	// left at ErrorFormatInherit use this one.
+	ErrorFormat ErrorFormat
+	// Middleware and Hooks around the Execute of every
+	// descendant command. Nested Subcommands add theirs after
+	// these. See lifecycle.run for the order they run in.
+	Middleware []Middleware
+	PreRun     []Hook
+	PostRun    []Hook
+	Finally    []Hook
+	inherited  lifecycle
+	// Adds the logging flags to every command below. Nested
+	// Subcommands without their own use this one.
+	Logging *LogConfig
+	logging *logFlags
+	// Adds -color, which picks when the help, the errors and the
+	// output are colored, to every command below.
+	ColorFlag bool
+	color     *string
+	// Ask for the missing required flags, and the positional
+	// arguments of ArgumentPrompters, when stdin is a terminal.
+	// Nested Subcommands inherit it.
+	PromptMissing bool
+	env           *Environment
 }
 
 func (self *Subcommands) Description() string {
@@ -41,21 +81,84 @@ func (self *Subcommands) Description() string {
 	return fmt.Sprintf("Subcommands: %s", strings.Join(names, ", "))
 }
 
+// environment is the Environment given to Invoke, or the
+// DefaultEnvironment when self is used without it.
+func (self *Subcommands) environment() *Environment {
+	if self.env == nil {
+		return DefaultEnvironment()
+	}
+	return self.env
+}
+
 func (self *Subcommands) Usage() {
+	env := self.environment()
+	styler := env.Styler(env.Logger.Writer())
 	for _, v := range self.Children {
-		log.Printf("  %-10s %-60s", v.FlagSet().Name(), v.Description())
+		env.Logger.Printf("  %s %-60s", styler.Style(STYLE_COMMAND, fmt.Sprintf("%-10s", v.FlagSet().Name())), v.Description())
+	}
+	for _, v := range self.FindPlugins() {
+		env.Logger.Printf("  %s %-60s", styler.Style(STYLE_COMMAND, fmt.Sprintf("%-10s", v.Name)), newPluginCommand(v).Description())
 	}
 }
 
+// errorFormat is the ErrorFormat of self, or of the closest parent
+// with one.
+func (self *Subcommands) errorFormat() ErrorFormat {
+	if self.ErrorFormat != ErrorFormatInherit {
+		return self.ErrorFormat
+	} else if self.parent != nil {
+		return self.parent.errorFormat()
+	}
+	return ErrorFormatText
+}
+
+// promptMissing is true when self, or one of its parents, has
+// PromptMissing.
+func (self *Subcommands) promptMissing() bool {
+	return self.PromptMissing || (self.parent != nil && self.parent.promptMissing())
+}
+
+// parentResponseFiles is true when a parent of self has
+// ResponseFiles, and so already expanded the arguments.
+func (self *Subcommands) parentResponseFiles() bool {
+	return self.parent != nil && (self.parent.ResponseFiles || self.parent.parentResponseFiles())
+}
+
+// ShowUsage is a Bare handler which logs the usage and
+// returns 0.
+func ShowUsage(ctx context.Context, self *Subcommands) int {
+	self.Usage()
+	return 0
+}
+
 func (self *Subcommands) FlagSet() *flag.FlagSet {
 	return self.flagSet
 }
 
 func (self *Subcommands) SetupSubcommand() Subcommand {
 	self.flagSet = flag.NewFlagSet(self.Name, flag.ExitOnError)
+	self.parent = nil
 	for i, v := range self.Children {
+		if nested, ok := v.(*Subcommands); ok {
+			nested.prefix = self.pluginPrefix() + "-" + nested.Name
+		}
 		self.Children[i] = v.SetupSubcommand()
+		if nested, ok := self.Children[i].(*Subcommands); ok {
+			nested.parent = self
+		}
 	}
+
+	self.logging = nil
+	if self.Logging != nil {
+		self.logging = newLogFlags(*self.Logging)
+		self.logging.addTo(self)
+	}
+	self.color = nil
+	if self.ColorFlag {
+		self.color = new(string)
+		addColorFlag(self, self.color)
+	}
+	addDestructiveFlags(self)
 	return self
 }
 
@@ -64,8 +167,26 @@ func (sef *Subcommands) HasInvalidFlags() bool {
 }
 
 func (self *Subcommands) Execute(ctx context.Context) int {
+	env := self.environment()
+	// The outermost Subcommands with ResponseFiles expands the
+	// arguments of the whole command line, once.
+	if self.ResponseFiles && !self.parentResponseFiles() && len(self.args) > 1 {
+		e := &responseFileExpander{cmd: self, maxDepth: RESPONSE_FILE_MAX_DEPTH, args: []string{}}
+		if err := e.expand(self.args[1:], 0); err != nil {
+			env.logf(slog.LevelError, "Invalid arguments. %s", err)
+			return 1
+		}
+		self.args = append(self.args[:1:1], e.args...)
+	}
+
+	if self.Default != "" && len(self.args) > 0 && (len(self.args) < 2 || strings.HasPrefix(self.args[1], "-")) {
+		self.args = append([]string{self.args[0], self.Default}, self.args[1:]...)
+	} else if self.Bare != nil && len(self.args) < 2 {
+		return self.Bare(ctx, self)
+	}
+
 	if len(self.args) < 2 {
-		log.Printf("Missing subcommand.")
+		env.logf(slog.LevelError, "Missing subcommand.")
 		self.Usage()
 		return 1
 	}
@@ -76,34 +197,89 @@ func (self *Subcommands) Execute(ctx context.Context) int {
 	var subcmd Subcommand
 	for _, v := range self.Children {
 		if v.FlagSet().Name() == myArgs[0] {
-			v.FlagSet().Parse(myArgs[1:])
+			// Only FlagSets which do not exit on errors get here
+			// with one. The FlagSet already printed it.
+			if err := v.FlagSet().Parse(myArgs[1:]); err == flag.ErrHelp {
+				return 0
+			} else if err != nil {
+				return 2
+			}
 			subcmd = v
 			break
 		}
 	}
 
+	// Not one of ours, but maybe a plugin.
+	if subcmd == nil {
+		if plugin, ok := self.lookupPlugin(myArgs[0]); ok {
+			subcmd = newPluginCommand(plugin)
+		}
+	}
+
 	// No subcommand, print the usage.
 	if subcmd == nil {
-		log.Printf("Unknown subcommand provided: %s.", myArgs[0])
+		env.logf(slog.LevelError, "Unknown subcommand provided: %s.", myArgs[0])
 		self.Usage()
 		return 1
 	}
 
+	if len(CommandPath(ctx)) == 0 {
+		ctx = withCommandPath(ctx, self.Name)
+	}
+	ctx = withCommandPath(ctx, myArgs[0])
+
+	// Only the command which is finally executed has all of its
+	// flags parsed, including the logging ones.
+	nested, isNested := subcmd.(*Subcommands)
+	if self.logging != nil && !isNested {
+		ctx = self.logging.attach(ctx)
+		env = Env(ctx)
+	}
+
+	// Whoever is at the terminal gets to fill in what is missing
+	// before it is reported. Nested Subcommands prompt themselves.
+	if self.promptMissing() && !isNested {
+		if p, ok := newPrompter(env); ok {
+			myArgs = p.fillIn(env, subcmd, myArgs)
+		}
+	}
+
+	// Flag values which can only be checked after parsing,
+	// like the PathFlag, and the declared constraints between
+	// flags are checked here. Every problem is reported at once.
+	format := self.errorFormat()
+	errs := ValidateFlags(subcmd.FlagSet())
+	if fc, ok := subcmd.(FlagConstrainer); ok {
+		errs = append(errs, CheckFlagConstraints(subcmd.FlagSet(), fc.FlagConstraints())...)
+	}
+	if len(errs) > 0 {
+		reportFieldErrors(env, format, subcmd.FlagSet(), errs)
+		return 1
+	}
+
 	// If the subcommand needs to convert the flagset into
 	// other data, it does it here.
 	if au, ok := subcmd.(ArgumentUnpacker); ok {
 		if err := au.UnpackArgs(); err != nil {
-			log.Printf("Invalid arguments. %s", err)
-			subcmd.FlagSet().PrintDefaults()
+			reportFieldErrors(env, format, subcmd.FlagSet(), FieldErrors(err))
+			return 1
+		}
+	}
+
+	// The subcommand can return the problems with its arguments
+	// here, and they are reported like the ones above.
+	if av, ok := subcmd.(ArgumentValidator); ok {
+		if errs := av.ValidateArgs(); len(errs) > 0 {
+			reportFieldErrors(env, format, subcmd.FlagSet(), errs)
 			return 1
 		}
 	}
 
 	// The subcommand can communicate if it has invalid data here.
 	// The subcommand is expected to output its own errors, but
-	// not the defaults.
+	// not the defaults. Prefer ArgumentValidator.
 	if subcmd.HasInvalidFlags() {
-		subcmd.FlagSet().PrintDefaults()
+		reportFieldErrors(env, format, subcmd.FlagSet(), nil)
 		return 1
 	}
 
@@ -111,33 +287,96 @@ func (self *Subcommands) Execute(ctx context.Context) int {
 		ac.SetArgs(myArgs)
 	}
 
-	return subcmd.Execute(ctx)
+	// Nested Subcommands inherit our settings, and only the
+	// command which is finally executed runs the lifecycle. Its
+	// flags, like the logging ones, were parsed above.
+	if isNested {
+		if nested.logging == nil {
+			nested.logging = self.logging
+		}
+		nested.inherited = self.inherited.extend(self)
+		nested.args = append([]string{myArgs[0]}, nested.FlagSet().Args()...)
+		return nested.Execute(ctx)
+	}
+
+	return self.inherited.extend(self).run(withDryRun(ctx, subcmd), subcmd)
 }
 
 func (self *Subcommands) SetArgs(args []string) {
 	self.args = args
 }
 
-func handleInterupt(ctx context.Context, cancel context.CancelFunc) {
+func handleInterupt(ctx context.Context, cancel context.CancelFunc, signals SignalNotifier) {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt)
+	signals.Notify(sigChan, os.Interrupt)
+	defer signals.Stop(sigChan)
 
 	for {
 		select {
 		case <-sigChan:
 			cancel()
 		case <-ctx.Done():
-			break
+			return
 		}
 	}
 }
 
-func Invoke(ctx context.Context, cmd Subcommand, args []string) int {
+// InvokeOption changes how Invoke runs the command.
+type InvokeOption func(*invocation)
+
+type invocation struct {
+	recoverPanics bool
+	crashDir      string
+	multicall     bool
+	afterSetup    []func(Subcommand)
+	clock         func() time.Time
+	env           *Environment
+}
+
+func Invoke(ctx context.Context, cmd Subcommand, args []string, opts ...InvokeOption) (rc int) {
+	inv := &invocation{}
+	for _, v := range opts {
+		v(inv)
+	}
+
+	// Commands run from a shell or a batch keep its Environment
+	// unless they are given their own.
+	if inv.env == nil {
+		if env, ok := ctx.Value(environmentKey{}).(*Environment); ok {
+			inv.env = env
+		}
+	}
+	inv.env = inv.env.withDefaults()
+	ctx = context.WithValue(ctx, environmentKey{}, inv.env)
+	ctx = withInvocationID(ctx)
+	ctx = context.WithValue(ctx, sessionOptionsKey{}, inv.sessionOptions())
+
 	var cancel context.CancelFunc
 	ctx, cancel = context.WithCancel(ctx)
-	go handleInterupt(ctx, cancel)
+	defer cancel()
+	go handleInterupt(ctx, cancel, inv.env.Signals)
+
+	if inv.clock != nil {
+		ctx = context.WithValue(ctx, clockKey{}, inv.clock)
+	}
 
 	setupCmd := cmd.SetupSubcommand()
+	useEnvironment(setupCmd, inv.env)
+	for _, v := range inv.afterSetup {
+		v(setupCmd)
+	}
+
+	if inv.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				rc = inv.reportPanic(setupCmd, args, r)
+			}
+		}()
+	}
+
+	if inv.multicall {
+		args = multicallArgs(setupCmd, args)
+	}
 
 	if nested, ok := setupCmd.(ArgumentConsumer); ok {
 		nested.SetArgs(args)
@@ -66,3 +66,41 @@ func TestInvoke(t *testing.T) {
 		t.Errorf("Error. Expected: 0. Received: %d.", rc)
 	}
 }
+
+func TestSubcommandsDefault(t *testing.T) {
+	StatusCommand := SubcommandNoOp{Name: "status", ExecuteInt: 3}
+	RootCommand := Subcommands{
+		Name:     "root",
+		Default:  "status",
+		Children: []Subcommand{&SubcommandNoOp{Name: "empty"}, &StatusCommand},
+	}
+
+	// No subcommand and only flags both run the default.
+	rc := Invoke(context.Background(), &RootCommand, []string{"cmd"})
+	if rc != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", rc)
+	}
+	rc = Invoke(context.Background(), &RootCommand, []string{"cmd", "--"})
+	if rc != 3 {
+		t.Errorf("Error. Expected: 3. Received: %d.", rc)
+	}
+	rc = Invoke(context.Background(), &RootCommand, []string{"cmd", "empty"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+
+	// A bare handler instead of the missing subcommand error.
+	BareCommand := Subcommands{
+		Name:     "bare",
+		Bare:     ShowUsage,
+		Children: []Subcommand{&SubcommandNoOp{Name: "empty", ExecuteInt: 1}},
+	}
+	rc = Invoke(context.Background(), &BareCommand, []string{"cmd"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+	rc = Invoke(context.Background(), &BareCommand, []string{"cmd", "empty"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+}
@@ -0,0 +1,136 @@
+package glarg
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FieldError describes one problem with the arguments of a
+// command. Value is empty when the problem is not about the value
+// of a flag, like a violated FlagConstraint, and Flag too when it
+// is not about flags at all.
+type FieldError struct {
+	Flag string `json:"flag,omitempty"`
+	// Every flag of a violated constraint, Flag being the first.
+	// The message names them already, so it is not prefixed with
+	// Flag.
+	Flags   []string `json:"flags,omitempty"`
+	Value   string   `json:"value,omitempty"`
+	Message string   `json:"message"`
+	Hint    string   `json:"hint,omitempty"`
+}
+
+func (self FieldError) Error() string {
+	if self.Flag == "" || len(self.Flags) > 0 {
+		return self.Message
+	}
+	return fmt.Sprintf("-%s: %s", self.Flag, self.Message)
+}
+
+// ValidationErrors lets UnpackArgs return several FieldErrors
+// as a single error.
+type ValidationErrors []FieldError
+
+func (self ValidationErrors) Error() string {
+	messages := make([]string, len(self))
+	for i, v := range self {
+		messages[i] = v.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ArgumentValidator is implemented by a Subcommand which checks
+// its own arguments after UnpackArgs. Unlike HasInvalidFlags, the
+// command returns the problems and Subcommands reports them, so
+// every command reports errors the same way. HasInvalidFlags is
+// still called afterwards.
+type ArgumentValidator interface {
+	ValidateArgs() []FieldError
+}
+
+// ErrorFormat is how Subcommands reports invalid arguments.
+type ErrorFormat int
+
+const (
+	// Use the format of the parent Subcommands, or
+	// ErrorFormatText at the root.
+	ErrorFormatInherit ErrorFormat = iota
+	// Log each error followed by the flag defaults.
+	ErrorFormatText
+	// Write a single {"errors": [...]} JSON document for
+	// machine callers.
+	ErrorFormatJSON
+)
+
+// FieldErrors converts err into FieldErrors. ValidationErrors and
+// FieldError are kept as is, any other error becomes the message.
+func FieldErrors(err error) []FieldError {
+	var ve ValidationErrors
+	var fe FieldError
+	if errors.As(err, &ve) {
+		return ve
+	} else if errors.As(err, &fe) {
+		return []FieldError{fe}
+	}
+	return []FieldError{{Message: err.Error()}}
+}
+
+// WriteFieldErrors writes errs to w in the given format.
+// The text format is the one used by Subcommands, without
+// the log prefix.
+func WriteFieldErrors(w io.Writer, format ErrorFormat, errs []FieldError) error {
+	if format == ErrorFormatJSON {
+		return json.NewEncoder(w).Encode(struct {
+			Errors []FieldError `json:"errors"`
+		}{errs})
+	}
+
+	for _, v := range errs {
+		if _, err := fmt.Fprintf(w, "Invalid arguments. %s\n", v); err != nil {
+			return err
+		}
+		if v.Hint != "" {
+			if _, err := fmt.Fprintf(w, "  hint: %s\n", v.Hint); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reportFieldErrors is how Subcommands reports invalid arguments
+// for all of the validation steps. Without errs, when the command
+// logged its own from HasInvalidFlags, the text format only prints
+// the defaults.
+func reportFieldErrors(env *Environment, format ErrorFormat, fs *flag.FlagSet, errs []FieldError) {
+	if format == ErrorFormatJSON {
+		if len(errs) == 0 {
+			errs = []FieldError{{Message: "invalid flags"}}
+		}
+		WriteFieldErrors(env.Logger.Writer(), format, errs)
+		return
+	}
+
+	styler := env.Styler(env.Logger.Writer())
+	for _, v := range errs {
+		if env.Slog != nil {
+			env.Slog.Error("Invalid arguments.", "flag", v.Flag, "value", v.Value, "message", v.Message, "hint", v.Hint)
+			continue
+		}
+		message := v.Message
+		if v.Flag != "" && len(v.Flags) == 0 {
+			message = styler.Style(STYLE_FLAG, "-"+v.Flag) + ": " + message
+		}
+		env.Logger.Printf("%s %s", styler.Style(STYLE_ERROR, "Invalid arguments."), message)
+		if v.Hint != "" {
+			env.Logger.Printf("  hint: %s", v.Hint)
+		}
+	}
+	if fs != nil {
+		printDefaults(env.Stderr, fs, env.Styler(env.Stderr))
+	}
+}
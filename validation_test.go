@@ -0,0 +1,148 @@
+package glarg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFieldErrors(t *testing.T) {
+	fe := FieldError{Flag: "id", Value: "x", Message: "invalid UUID length: 1", Hint: "use uuidgen"}
+	if fe.Error() != "-id: invalid UUID length: 1" {
+		t.Errorf("Error. Unexpected error. Received: %s", fe.Error())
+	}
+
+	ve := ValidationErrors{fe, {Message: "one of -id or -url is required"}}
+	if ve.Error() != "-id: invalid UUID length: 1; one of -id or -url is required" {
+		t.Errorf("Error. Unexpected error. Received: %s", ve.Error())
+	}
+
+	if errs := FieldErrors(ve); len(errs) != 2 {
+		t.Errorf("Error. Expected: 2. Received: %d.", len(errs))
+	}
+	if errs := FieldErrors(fmt.Errorf("wrapped: %w", fe)); len(errs) != 1 || errs[0].Flag != "id" {
+		t.Errorf("Error. Expected the wrapped FieldError. Received: %v.", errs)
+	}
+	if errs := FieldErrors(fmt.Errorf("plain")); len(errs) != 1 || errs[0].Message != "plain" {
+		t.Errorf("Error. Expected the plain message. Received: %v.", errs)
+	}
+
+	var out bytes.Buffer
+	WriteFieldErrors(&out, ErrorFormatText, ve)
+	expected := "Invalid arguments. -id: invalid UUID length: 1\n  hint: use uuidgen\nInvalid arguments. one of -id or -url is required\n"
+	if out.String() != expected {
+		t.Errorf("Error. Expected: %q. Received: %q.", expected, out.String())
+	}
+
+	out.Reset()
+	WriteFieldErrors(&out, ErrorFormatJSON, ve)
+	var doc struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Errorf("Error. Expected JSON. Received: %s", err)
+	} else if len(doc.Errors) != 2 || !reflect.DeepEqual(doc.Errors[0], fe) {
+		t.Errorf("Error. Unexpected JSON. Received: %s", out.String())
+	}
+}
+
+type validationTestCommand struct {
+	SubcommandNoOp
+	errs []FieldError
+}
+
+func (self *validationTestCommand) SetupSubcommand() Subcommand {
+	self.SubcommandNoOp.SetupSubcommand()
+	return self
+}
+
+func (self *validationTestCommand) ValidateArgs() []FieldError {
+	return self.errs
+}
+
+func TestSubcommandsArgumentValidator(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	cmd := &validationTestCommand{SubcommandNoOp: SubcommandNoOp{Name: "get"}}
+	root := &Subcommands{
+		Name:        "root",
+		ErrorFormat: ErrorFormatJSON,
+		Children:    []Subcommand{&Subcommands{Name: "nested", Children: []Subcommand{cmd}}},
+	}
+
+	rc := Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 0 {
+		t.Errorf("Error. Expected: 0. Received: %d.", rc)
+	}
+
+	cmd.errs = []FieldError{{Flag: "id", Message: "not found"}, {Flag: "url", Message: "bad scheme"}}
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	// The nested Subcommands inherits the JSON format.
+	if !strings.Contains(out.String(), `{"errors":[{"flag":"id","message":"not found"},{"flag":"url","message":"bad scheme"}]}`) {
+		t.Errorf("Error. Expected JSON errors. Received: %s", out.String())
+	}
+
+	// UnpackArgs errors are reported the same way.
+	out.Reset()
+	cmd.errs = nil
+	cmd.UnpackArgsError = ValidationErrors{{Flag: "id", Message: "unpack"}}
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(out.String(), `"message":"unpack"`) {
+		t.Errorf("Error. Expected JSON errors. Received: %s", out.String())
+	}
+	// As are the invalid flags a command reports itself.
+	out.Reset()
+	cmd.UnpackArgsError = nil
+	cmd.InvalidFlagsBool = true
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if out.String() != `{"errors":[{"message":"invalid flags"}]}`+"\n" {
+		t.Errorf("Error. Expected JSON errors. Received: %s", out.String())
+	}
+
+	// The nested Subcommands keeps its own format.
+	nested := root.Children[0].(*Subcommands)
+	if nested.ErrorFormat != ErrorFormatInherit {
+		t.Errorf("Error. Expected: %d. Received: %d.", ErrorFormatInherit, nested.ErrorFormat)
+	}
+	out.Reset()
+	root.ErrorFormat = ErrorFormatInherit
+	cmd.InvalidFlagsBool = false
+	cmd.errs = []FieldError{{Flag: "id", Message: "not found"}}
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(out.String(), "Invalid arguments. -id: not found") {
+		t.Errorf("Error. Expected text errors. Received: %s", out.String())
+	}
+
+	// A nested Subcommands can pick the text format under a JSON
+	// root.
+	out.Reset()
+	root.ErrorFormat = ErrorFormatJSON
+	nested.ErrorFormat = ErrorFormatText
+	rc = Invoke(context.Background(), root, []string{"tool", "nested", "get"})
+	if rc != 1 {
+		t.Errorf("Error. Expected: 1. Received: %d.", rc)
+	}
+	if !strings.Contains(out.String(), "Invalid arguments. -id: not found") {
+		t.Errorf("Error. Expected text errors. Received: %s", out.String())
+	}
+}